@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"ledger-service/internal/core/services/ledger"
+	"ledger-service/internal/core/types"
 	"ledger-service/internal/infrastructure/config"
 	"ledger-service/internal/infrastructure/db"
 	"ledger-service/internal/infrastructure/queue"
@@ -21,6 +22,16 @@ import (
 func main() {
 	cfg := config.LoadFromEnv()
 
+	defaultCurrency := types.Currency(cfg.DefaultCurrency)
+	if !defaultCurrency.Valid() {
+		log.Fatalf("Unsupported default currency: %s", cfg.DefaultCurrency)
+	}
+
+	commissionComponents, err := types.ParseOrderComponents(cfg.CommissionComponents)
+	if err != nil {
+		log.Fatalf("Invalid commission components: %v", err)
+	}
+
 	client, err := db.NewMongoClient(cfg.MongoURI)
 	if err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
@@ -28,9 +39,66 @@ func main() {
 
 	transactionRepo := mongo.NewTransactionRepository(client, cfg.DatabaseName, cfg.TransactionCollection)
 	balanceRepo := mongo.NewBalanceRepository(client, cfg.DatabaseName, cfg.BalanceCollection)
+	outboxRepo := mongo.NewOutboxRepository(client, cfg.DatabaseName, cfg.OutboxCollection)
+	idempotencyRepo := mongo.NewIdempotencyRepository(client, cfg.DatabaseName, cfg.IdempotencyCollection)
+	postingRepo := mongo.NewPostingRepository(client, cfg.DatabaseName, cfg.PostingCollection)
+	commissionPolicyRepo := mongo.NewCommissionPolicyRepository(client, cfg.DatabaseName, cfg.CommissionPolicyCollection)
+	snapshotRepo := mongo.NewBalanceSnapshotRepository(client, cfg.DatabaseName, cfg.BalanceSnapshotCollection)
+	migrationLog := mongo.NewMigrationLog(client, cfg.DatabaseName, cfg.MigrationCollection)
+	leaseRepo := mongo.NewLeaseRepository(client, cfg.DatabaseName, cfg.LeaseCollection)
+
+	instance := ledger.LeaseHolder()
+	lease, err := acquireLease(leaseRepo, instance)
+	if err != nil {
+		log.Fatalf("Failed to start: %v", err)
+	}
+
+	if err := migrate(transactionRepo, balanceRepo, outboxRepo, postingRepo, defaultCurrency, cfg.PlatformAccountId); err != nil {
+		log.Fatalf("Failed to migrate existing documents: %v", err)
+	}
+
+	if err := resetSnapshots(snapshotRepo, migrationLog); err != nil {
+		log.Fatalf("Failed to reset balance snapshots: %v", err)
+	}
+
+	if err := ensureIndexes(transactionRepo, idempotencyRepo, postingRepo, commissionPolicyRepo, snapshotRepo); err != nil {
+		log.Fatalf("Failed to create indexes: %v", err)
+	}
+
 	taskQueue := queue.NewInMemoryQueue()
 
-	ledgerService := ledger.NewService(transactionRepo, balanceRepo, taskQueue)
+	ledgerService := ledger.NewService(ledger.Repositories{
+		Transactions:       transactionRepo,
+		Balances:           balanceRepo,
+		Outbox:             outboxRepo,
+		Idempotency:        idempotencyRepo,
+		Postings:           postingRepo,
+		CommissionPolicies: commissionPolicyRepo,
+		Snapshots:          snapshotRepo,
+		Leases:             leaseRepo,
+	}, taskQueue, ledger.Config{
+		DefaultCurrency:         defaultCurrency,
+		PlatformAccount:         cfg.PlatformAccountId,
+		OutboxPollInterval:      cfg.OutboxPollInterval,
+		HoldTTL:                 cfg.HoldTTL,
+		HoldSweepInterval:       cfg.HoldSweepInterval,
+		EscrowClearingPeriod:    cfg.EscrowClearingPeriod,
+		EscrowSweepInterval:     cfg.EscrowSweepInterval,
+		CommissionComponents:    commissionComponents,
+		BalanceSnapshotInterval: cfg.BalanceSnapshotInterval,
+		ReconciliationInterval:  cfg.ReconciliationInterval,
+		ReconciliationRepair:    cfg.ReconciliationRepair,
+		Instance:                instance,
+	})
+
+	if err := backfillJournal(ledgerService, migrationLog); err != nil {
+		log.Fatalf("Failed to backfill journal: %v", err)
+	}
+	if err := backfillEscrowFlags(ledgerService, migrationLog); err != nil {
+		log.Fatalf("Failed to backfill escrow flags: %v", err)
+	}
+
+	ledgerService.Start()
 
 	server := web.NewServer(ledgerService)
 
@@ -49,10 +117,143 @@ func main() {
 		}
 	}()
 
-	gracefulShutdown(httpServer, ledgerService)
+	gracefulShutdown(httpServer, ledgerService, lease)
+}
+
+// acquireLease registers this process as a running service. It fails while
+// a maintenance command such as rebuild-balances holds the database.
+func acquireLease(leaseRepo *mongo.LeaseRepository, instance string) (*ledger.Lease, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := leaseRepo.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+	return ledger.AcquireLease(ctx, leaseRepo, ledger.SERVICE_LEASE, instance, ledger.MAINTENANCE_LEASE)
+}
+
+// migrate upgrades documents written by older versions. Legacy documents
+// carry no currency and are treated as being in the default currency.
+func migrate(transactionRepo *mongo.TransactionRepository, balanceRepo *mongo.BalanceRepository, outboxRepo *mongo.OutboxRepository, postingRepo *mongo.PostingRepository, defaultCurrency types.Currency, platformAccount string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := transactionRepo.MigrateFloatAmounts(ctx, defaultCurrency); err != nil {
+		return err
+	}
+	if err := balanceRepo.MigrateFloatAmounts(ctx, defaultCurrency); err != nil {
+		return err
+	}
+	if err := transactionRepo.MigrateCurrency(ctx, defaultCurrency); err != nil {
+		return err
+	}
+	if err := balanceRepo.MigrateCurrency(ctx, defaultCurrency); err != nil {
+		return err
+	}
+
+	pendingIds, err := outboxRepo.UnappliedTransactionIds(ctx)
+	if err != nil {
+		return err
+	}
+	if err := transactionRepo.MigrateStatus(ctx, pendingIds); err != nil {
+		return err
+	}
+	if err := transactionRepo.MigrateEmptyParties(ctx); err != nil {
+		return err
+	}
+	if err := transactionRepo.MigratePlatform(ctx, platformAccount); err != nil {
+		return err
+	}
+	if err := transactionRepo.MigrateCommissionRate(ctx, ledger.COMMISSION_RATE); err != nil {
+		return err
+	}
+
+	platformAccounts, err := transactionRepo.PlatformAccounts(ctx)
+	if err != nil {
+		return err
+	}
+	return postingRepo.MigratePlatform(ctx, append(platformAccounts, platformAccount))
+}
+
+// resetSnapshots drops the snapshots taken while they summed commission
+// postings instead of totalling the commission earned or paid, so that they
+// are taken again from the journal. It runs once.
+func resetSnapshots(snapshotRepo *mongo.BalanceSnapshotRepository, migrationLog *mongo.MigrationLog) error {
+	const name = "snapshot-commission-reset"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	done, err := migrationLog.Done(ctx, name)
+	if err != nil || done {
+		return err
+	}
+
+	if err := snapshotRepo.DeleteAll(ctx); err != nil {
+		return err
+	}
+	return migrationLog.MarkDone(ctx, name)
+}
+
+func ensureIndexes(transactionRepo *mongo.TransactionRepository, idempotencyRepo *mongo.IdempotencyRepository, postingRepo *mongo.PostingRepository, commissionPolicyRepo *mongo.CommissionPolicyRepository, snapshotRepo *mongo.BalanceSnapshotRepository) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := transactionRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	if err := idempotencyRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	if err := postingRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	if err := commissionPolicyRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+	return snapshotRepo.EnsureIndexes(ctx)
+}
+
+// backfillJournal writes postings for transactions posted before the journal
+// existed. It runs once; an interrupted run is resumed on the next startup.
+func backfillJournal(ledgerService *ledger.Service, migrationLog *mongo.MigrationLog) error {
+	const name = "journal-backfill"
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	done, err := migrationLog.Done(ctx, name)
+	if err != nil || done {
+		return err
+	}
+
+	if err := ledgerService.BackfillJournal(ctx); err != nil {
+		return err
+	}
+	return migrationLog.MarkDone(ctx, name)
 }
 
-func gracefulShutdown(httpServer *http.Server, ledgerService *ledger.Service) {
+// backfillEscrowFlags marks the refunds and reversals booked against escrow
+// before that was recorded on them, so that reconciliation can make their
+// postings again. It runs once.
+func backfillEscrowFlags(ledgerService *ledger.Service, migrationLog *mongo.MigrationLog) error {
+	const name = "escrow-flag-backfill"
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	done, err := migrationLog.Done(ctx, name)
+	if err != nil || done {
+		return err
+	}
+
+	if err := ledgerService.BackfillEscrowFlags(ctx); err != nil {
+		return err
+	}
+	return migrationLog.MarkDone(ctx, name)
+}
+
+func gracefulShutdown(httpServer *http.Server, ledgerService *ledger.Service, lease *ledger.Lease) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -68,5 +269,9 @@ func gracefulShutdown(httpServer *http.Server, ledgerService *ledger.Service) {
 
 	ledgerService.Shutdown()
 
+	if err := lease.Release(ctx); err != nil {
+		log.Printf("Failed to release lease: %v", err)
+	}
+
 	fmt.Println("Server stopped")
 }
@@ -0,0 +1,130 @@
+// Command rebuild-balances recomputes the balances and postings collections
+// from the transactions. It books every posted transaction again with the
+// current journal into shadow collections and, unless -dry-run is given,
+// swaps them in for the live ones, keeping those as a backup, and drops the
+// balance snapshots taken from the old postings. It refuses to run while a
+// ledger service is running, and the service refuses to start while it runs.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"ledger-service/internal/core/services/ledger"
+	"ledger-service/internal/infrastructure/config"
+	"ledger-service/internal/infrastructure/db"
+	"ledger-service/internal/infrastructure/repository/mongo"
+
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "rebuild into the shadow collections and report the changes without swapping them in")
+	flag.Parse()
+
+	cfg := config.LoadFromEnv()
+
+	client, err := db.NewMongoClient(cfg.MongoURI)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	leaseRepo := mongo.NewLeaseRepository(client, cfg.DatabaseName, cfg.LeaseCollection)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := leaseRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create lease indexes: %v", err)
+	}
+	lease, err := ledger.AcquireLease(ctx, leaseRepo, ledger.MAINTENANCE_LEASE, ledger.LeaseHolder(),
+		ledger.SERVICE_LEASE, ledger.MAINTENANCE_LEASE)
+	if err != nil {
+		log.Fatalf("Stop the ledger service before rebuilding: %v", err)
+	}
+
+	err = run(ctx, cfg, client, *dryRun)
+
+	// Released before exiting on failure too, so that the service can start
+	// again right away.
+	releaseCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if releaseErr := lease.Release(releaseCtx); releaseErr != nil {
+		log.Printf("Failed to release the maintenance lease, it lapses on its own: %v", releaseErr)
+	}
+	cancel()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, client *mongodriver.Client, dryRun bool) error {
+	transactionRepo := mongo.NewTransactionRepository(client, cfg.DatabaseName, cfg.TransactionCollection)
+	balanceRepo := mongo.NewBalanceRepository(client, cfg.DatabaseName, cfg.BalanceCollection)
+	postingRepo := mongo.NewPostingRepository(client, cfg.DatabaseName, cfg.PostingCollection)
+	snapshotRepo := mongo.NewBalanceSnapshotRepository(client, cfg.DatabaseName, cfg.BalanceSnapshotCollection)
+
+	shadowRepo, err := balanceRepo.Shadow(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to prepare the shadow balances: %w", err)
+	}
+	shadowPostingRepo, err := postingRepo.Shadow(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to prepare the shadow postings: %w", err)
+	}
+
+	report, err := ledger.RebuildBalances(ctx, ledger.Repositories{
+		Transactions: transactionRepo,
+		Balances:     balanceRepo,
+		Postings:     postingRepo,
+	}, ledger.Config{
+		PlatformAccount: cfg.PlatformAccountId,
+	}, shadowRepo, shadowPostingRepo)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild balances: %w", err)
+	}
+
+	fmt.Printf("Booked %d postings of %d transactions into %d wallets in %s\n",
+		report.Postings, report.Transactions, report.Wallets, report.FinishedAt.Sub(report.StartedAt))
+	for _, changed := range report.Changed {
+		fmt.Printf("  %s %s: amount %d -> %d, escrow %d -> %d, total commission %d -> %d\n",
+			changed.Account, changed.Currency,
+			changed.StoredAmount, changed.ExpectedAmount,
+			changed.StoredEscrow, changed.ExpectedEscrow,
+			changed.StoredCommission, changed.ExpectedCommission)
+	}
+	fmt.Printf("%d wallets changed\n", len(report.Changed))
+
+	if dryRun {
+		fmt.Printf("Dry run: the rebuilt balances and postings were left in %s_rebuild and %s_rebuild\n",
+			cfg.BalanceCollection, cfg.PostingCollection)
+		return nil
+	}
+
+	// Each swap is atomic on its own. If the balances cannot be swapped in
+	// after the postings were, the previous postings are put back so that
+	// the two stay in line.
+	if err := postingRepo.Replace(ctx, shadowPostingRepo); err != nil {
+		return fmt.Errorf("failed to swap in the rebuilt postings, nothing was changed: %w", err)
+	}
+	if err := balanceRepo.Replace(ctx, shadowRepo); err != nil {
+		if restoreErr := postingRepo.Restore(context.Background()); restoreErr != nil {
+			return fmt.Errorf("failed to swap in the rebuilt balances (%w), and restoring the previous postings from %s_backup failed too (%w): "+
+				"the rebuilt postings are live with the previous balances, run the rebuild again", err, cfg.PostingCollection, restoreErr)
+		}
+		return fmt.Errorf("failed to swap in the rebuilt balances, the previous postings were restored: %w", err)
+	}
+	if err := snapshotRepo.DeleteAll(ctx); err != nil {
+		return fmt.Errorf("swapped in the rebuilt balances and postings but failed to drop the balance snapshots, drop %s before starting the service: %w",
+			cfg.BalanceSnapshotCollection, err)
+	}
+	fmt.Printf("Swapped in the rebuilt balances and postings; the previous ones are in %s_backup and %s_backup\n",
+		cfg.BalanceCollection, cfg.PostingCollection)
+	return nil
+}
@@ -4,11 +4,36 @@ db = db.getSiblingDB('ledger');
 // Create collections
 db.createCollection('transactions');
 db.createCollection('balances');
+db.createCollection('outbox');
+db.createCollection('idempotency_keys');
+db.createCollection('postings');
+db.createCollection('migrations');
+db.createCollection('commission_policies');
+db.createCollection('balance_snapshots');
 
 // Create indexes for better performance
-db.transactions.createIndex({ "customer.id": 1 });
-db.transactions.createIndex({ "restaurant.id": 1 });
+db.transactions.createIndex({ "customer.id": 1, "created_at": -1, "id": -1 });
+db.transactions.createIndex({ "recipient.id": 1, "created_at": -1, "id": -1 });
+db.transactions.createIndex({ "restaurant.id": 1, "created_at": -1, "id": -1 });
+db.transactions.createIndex({ "restaurant.id": 1, "type": 1, "currency": 1, "created_at": 1 });
+db.transactions.createIndex({ "platform.id": 1, "created_at": -1, "id": -1 });
+db.transactions.createIndex({ "courier.id": 1, "created_at": -1, "id": -1 });
 db.transactions.createIndex({ "type": 1 });
-db.balances.createIndex({ "userid": 1 }, { unique: true });
+db.transactions.createIndex({ "related_transaction": 1 });
+db.transactions.createIndex({ "hold_status": 1, "expires_at": 1 });
+db.transactions.createIndex({ "escrow_status": 1, "escrow_release_at": 1 });
+db.transactions.createIndex({ "id": 1 }, { unique: true });
+db.balances.createIndex({ "userid": 1, "currency": 1 }, { unique: true });
+db.outbox.createIndex({ "transaction_id": 1 }, { unique: true });
+db.outbox.createIndex({ "dispatched_at": 1, "applied_at": 1, "created_at": 1 });
+db.idempotency_keys.createIndex({ "key": 1 }, { unique: true });
+db.postings.createIndex({ "id": 1 }, { unique: true });
+db.postings.createIndex({ "transaction_id": 1 });
+db.postings.createIndex({ "account": 1, "currency": 1, "posted_at": 1 });
+db.postings.createIndex({ "posted_at": 1 });
+db.postings.createIndex({ "account": 1, "posted_at": 1, "id": 1 });
+db.commission_policies.createIndex({ "restaurant_id": 1 }, { unique: true });
+db.balance_snapshots.createIndex({ "account": 1, "currency": 1, "as_of": -1 });
+db.balance_snapshots.createIndex({ "as_of": -1 });
 
 print('Database initialized successfully');
\ No newline at end of file
@@ -3,16 +3,195 @@ package interfaces
 import (
 	"context"
 	"ledger-service/internal/core/types"
+	"time"
 )
 
+// UnitOfWork runs fn inside a database transaction. Repository calls made
+// with the context passed to fn take part in it; calls made while already
+// inside a transaction join the outer one.
+type UnitOfWork interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type TransactionRepository interface {
+	UnitOfWork
 	Save(ctx context.Context, t types.Transaction) (string, error)
-	GetManyForCustomer(ctx context.Context, id string) ([]types.Transaction, error)
-	GetManyForRestaurant(ctx context.Context, id string) ([]types.Transaction, error)
+	GetById(ctx context.Context, id string) (types.Transaction, error)
+	// GetByIds returns the transactions with the given ids that exist, in no
+	// particular order.
+	GetByIds(ctx context.Context, ids []string) ([]types.Transaction, error)
+	// MarkPosted moves a PENDING transaction to POSTED. It returns false if
+	// the transaction was not pending anymore.
+	MarkPosted(ctx context.Context, id string, postedAt time.Time) (bool, error)
+	// MarkFailed moves a PENDING transaction to FAILED. It returns false if
+	// the transaction was not pending anymore.
+	MarkFailed(ctx context.Context, id string, reason string) (bool, error)
+	// TransitionPayout moves a PENDING payout from one of the from statuses to
+	// the to status. It returns false if the payout was in none of them.
+	TransitionPayout(ctx context.Context, id string, from []types.PayoutStatus, to types.PayoutStatus) (bool, error)
+	// ResolveHold moves an ACTIVE hold to the to status. It returns false if
+	// the hold was not active anymore.
+	ResolveHold(ctx context.Context, id string, to types.HoldStatus) (bool, error)
+	// GetExpiredHolds returns ACTIVE holds that expired at or before now.
+	GetExpiredHolds(ctx context.Context, now time.Time) ([]types.Transaction, error)
+	// EscrowProceeds records that a purchase's proceeds are HELD in escrow
+	// until releaseAt.
+	EscrowProceeds(ctx context.Context, purchaseId string, amount types.Money, releaseAt time.Time) error
+	// DebitEscrow takes amount off a purchase's proceeds that are still HELD
+	// and covered by them. It returns false otherwise.
+	DebitEscrow(ctx context.Context, purchaseId string, amount types.Money) (bool, error)
+	// RestoreEscrow puts amount back into a purchase's proceeds if they are
+	// still HELD. It returns false otherwise.
+	RestoreEscrow(ctx context.Context, purchaseId string, amount types.Money) (bool, error)
+	// MarkEscrowed records that a refund or reversal was booked against
+	// escrow.
+	MarkEscrowed(ctx context.Context, id string) error
+	// SetLegs records the legs of the purchase a refund gives back.
+	SetLegs(ctx context.Context, id string, legs []types.Leg) error
+	// CloseEscrow moves a purchase's proceeds from HELD to RELEASED, recording
+	// deliveredAt if set, and returns the purchase as it was before. It
+	// returns false if they were not HELD.
+	CloseEscrow(ctx context.Context, purchaseId string, deliveredAt *time.Time) (types.Transaction, bool, error)
+	// GetEscrowDue returns purchases whose proceeds are HELD past their
+	// release time.
+	GetEscrowDue(ctx context.Context, now time.Time) ([]types.Transaction, error)
+	// ReserveRefund adds amount to a POSTED purchase's refunded amount if
+	// what remains refundable covers it, and fails with
+	// types.ErrRefundExceedsPurchase otherwise.
+	ReserveRefund(ctx context.Context, purchaseId string, amount types.Money) error
+	// ReleaseRefund gives amount back to the purchase's refundable amount.
+	ReleaseRefund(ctx context.Context, purchaseId string, amount types.Money) error
+	// ClaimReversal marks a POSTED transaction that was not reversed yet as
+	// reversed. It fails with types.ErrAlreadyReversed if it was, and with
+	// types.ErrNotReversible if it is not POSTED, was partly refunded or is of
+	// a type that cannot be reversed.
+	ClaimReversal(ctx context.Context, id string) error
+	// ReleaseReversal clears the reversed mark after a reversal failed.
+	ReleaseReversal(ctx context.Context, id string) error
+	// GetRelated returns the transactions whose RelatedTransaction is id.
+	GetRelated(ctx context.Context, id string) ([]types.Transaction, error)
+	// ForEachPosted calls fn for every POSTED transaction, oldest first.
+	// Holds, which are POSTED but book no postings, are skipped.
+	ForEachPosted(ctx context.Context, fn func(types.Transaction) error) error
+	// GetManyForCustomer, GetManyForRestaurant and GetManyForCourier return
+	// up to limit of the party's transactions matching filter, newest first
+	// and starting after the cursor if it is set. A zero limit returns all of
+	// them.
+	GetManyForCustomer(ctx context.Context, id string, filter types.TransactionFilter, after *types.Cursor, limit int) ([]types.Transaction, error)
+	GetManyForRestaurant(ctx context.Context, id string, filter types.TransactionFilter, after *types.Cursor, limit int) ([]types.Transaction, error)
+	// EachForCustomer and EachForRestaurant stream the party's transactions
+	// matching filter to fn, oldest first, without loading them all.
+	EachForCustomer(ctx context.Context, id string, filter types.TransactionFilter, fn func(types.Transaction) error) error
+	EachForRestaurant(ctx context.Context, id string, filter types.TransactionFilter, fn func(types.Transaction) error) error
+	// GetManyForCourier lists the purchases delivered by the courier.
+	GetManyForCourier(ctx context.Context, id string, filter types.TransactionFilter, after *types.Cursor, limit int) ([]types.Transaction, error)
+	// PurchaseVolume totals the restaurant's POSTED purchases in currency
+	// created in [from, to).
+	PurchaseVolume(ctx context.Context, restaurantId string, currency types.Currency, from, to time.Time) (types.Money, error)
 }
 
 type BalanceRepository interface {
-	GetBalance(ctx context.Context, userId string) (types.Balance, error)
-	UpdateBalance(ctx context.Context, userId string, amount float32) error
-	UpdateTotalCommission(ctx context.Context, userId string, amount float32) error
+	UnitOfWork
+	GetBalance(ctx context.Context, userId string, currency types.Currency) (types.Balance, error)
+	GetBalances(ctx context.Context, userId string) ([]types.Balance, error)
+	// ForEach calls fn for every wallet, without loading them all.
+	ForEach(ctx context.Context, fn func(types.Balance) error) error
+	// UpdateBalance and UpdateEscrow add amount to the balance or escrow of
+	// the wallet, creating it if needed, and return the wallet as updated.
+	UpdateBalance(ctx context.Context, userId string, currency types.Currency, amount types.Money) (types.Balance, error)
+	UpdateEscrow(ctx context.Context, userId string, currency types.Currency, amount types.Money) (types.Balance, error)
+	UpdateTotalCommission(ctx context.Context, userId string, currency types.Currency, amount types.Money) error
+	// Reserve sets amount aside if the available balance plus overdraft limit
+	// covers it, and fails with types.ErrInsufficientFunds otherwise.
+	Reserve(ctx context.Context, userId string, currency types.Currency, amount types.Money) error
+	// ReserveAvailable is Reserve without the overdraft limit: it only
+	// succeeds if the balance not reserved yet covers amount.
+	ReserveAvailable(ctx context.Context, userId string, currency types.Currency, amount types.Money) error
+	UpdateReserved(ctx context.Context, userId string, currency types.Currency, amount types.Money) error
+	SetOverdraftLimit(ctx context.Context, userId string, currency types.Currency, limit types.Money) (types.Balance, error)
+}
+
+type OutboxRepository interface {
+	Add(ctx context.Context, entry types.OutboxEntry) error
+	// ClaimNext marks the oldest undispatched entry as dispatched to
+	// instance and returns it, or returns false when there is nothing to
+	// dispatch.
+	ClaimNext(ctx context.Context, instance string) (types.OutboxEntry, bool, error)
+	// ResetDispatched makes every entry that was not applied yet eligible for
+	// dispatch again, except those dispatched to one of the instances in
+	// keep.
+	ResetDispatched(ctx context.Context, keep []string) error
+	// Redispatch makes the transaction's entry eligible for dispatch again
+	// if it was not applied yet.
+	Redispatch(ctx context.Context, transactionId string) error
+	// RecordFailure counts a dispatch of the transaction's entry that failed
+	// with reason and returns how many have failed so far.
+	RecordFailure(ctx context.Context, transactionId, reason string) (int, error)
+	// DeadLetter parks the transaction's entry: it is not dispatched again,
+	// not even on restart.
+	DeadLetter(ctx context.Context, transactionId string) error
+	UnappliedTransactionIds(ctx context.Context) ([]string, error)
+	// MarkApplied records that the worker is done with the transaction,
+	// whether it was posted or failed.
+	MarkApplied(ctx context.Context, transactionId string) error
+}
+
+type IdempotencyRepository interface {
+	Get(ctx context.Context, key string) (types.IdempotencyRecord, bool, error)
+	// Create stores the record, returning types.ErrIdempotencyKeyExists if a
+	// record with the same key exists.
+	Create(ctx context.Context, record types.IdempotencyRecord) error
+}
+
+type CommissionPolicyRepository interface {
+	// Get returns the restaurant's policy, or the default policy for an empty
+	// restaurantId, and false if none is stored.
+	Get(ctx context.Context, restaurantId string) (types.CommissionPolicy, bool, error)
+	GetAll(ctx context.Context) ([]types.CommissionPolicy, error)
+	Upsert(ctx context.Context, policy types.CommissionPolicy) (types.CommissionPolicy, error)
+	// Delete removes the policy, returning false if there was none.
+	Delete(ctx context.Context, restaurantId string) (bool, error)
+}
+
+type PostingRepository interface {
+	SaveMany(ctx context.Context, postings []types.Posting) error
+	GetForTransaction(ctx context.Context, transactionId string) ([]types.Posting, error)
+	// SumByCurrency totals the account's postings of the given kind booked in
+	// [from, to), per currency.
+	SumByCurrency(ctx context.Context, account string, kind types.PostingKind, from, to time.Time) ([]types.AccountTotal, error)
+	// GetHistory returns up to limit of the account's postings booked in
+	// [from, to), optionally in one currency, starting after the posting the
+	// cursor points at if it is set. They are ordered by currency and then in
+	// the order they were applied. Zero times leave the range open.
+	GetHistory(ctx context.Context, account string, currency types.Currency, from, to time.Time, after *types.Cursor, limit int) ([]types.Posting, error)
+	// Totals sums the postings booked in (from, to] per account and currency,
+	// optionally for one account and currency only. Zero times leave the
+	// range open. The AsOf of the results is not set.
+	Totals(ctx context.Context, account string, currency types.Currency, from, to time.Time) ([]types.BalanceSnapshot, error)
+}
+
+type BalanceSnapshotRepository interface {
+	// SaveMany saves the snapshots, replacing any the account already has
+	// in the same currency as of the same time.
+	SaveMany(ctx context.Context, snapshots []types.BalanceSnapshot) error
+	// MarkTaken records that the snapshots as of asOf were all saved.
+	MarkTaken(ctx context.Context, asOf time.Time) error
+	// Latest returns the account's most recent snapshot in currency taken as
+	// of asOf or earlier, and false if there is none.
+	Latest(ctx context.Context, account string, currency types.Currency, asOf time.Time) (types.BalanceSnapshot, bool, error)
+	// LastAsOf returns the time of the most recent snapshot run that
+	// completed.
+	LastAsOf(ctx context.Context) (time.Time, bool, error)
+}
+
+// LeaseRepository records which processes are running, so that those that
+// must not run at the same time can keep each other out. A lease lapses
+// unless its holder renews it before it expires.
+type LeaseRepository interface {
+	// Renew takes or extends holder's lease on name until ttl from now.
+	Renew(ctx context.Context, name, holder string, ttl time.Duration) error
+	// Holders returns the holders of the leases on name that have not expired.
+	Holders(ctx context.Context, name string) ([]string, error)
+	// Release gives up holder's lease on name.
+	Release(ctx context.Context, name, holder string) error
 }
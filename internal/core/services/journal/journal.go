@@ -0,0 +1,207 @@
+package journal
+
+import (
+	"fmt"
+	"ledger-service/internal/core/types"
+	"time"
+)
+
+// System accounts that are not owned by a user.
+const (
+	// PLATFORM_REVENUE_ACCOUNT is the default account that receives every
+	// commission.
+	PLATFORM_REVENUE_ACCOUNT = "platform:revenue"
+	// EXTERNAL_FUNDING_ACCOUNT is the counterpart of money entering the
+	// ledger from outside, e.g. customer deposits.
+	EXTERNAL_FUNDING_ACCOUNT = "external:funding"
+	// EXTERNAL_PAYOUT_ACCOUNT is the counterpart of money leaving the ledger,
+	// e.g. restaurant payouts.
+	EXTERNAL_PAYOUT_ACCOUNT = "external:payouts"
+)
+
+type Journal struct {
+	platformAccount string
+	fundingAccount  string
+	payoutAccount   string
+}
+
+// New returns a journal that credits commission to platformAccount, or to
+// PLATFORM_REVENUE_ACCOUNT when it is empty.
+func New(platformAccount string) *Journal {
+	if platformAccount == "" {
+		platformAccount = PLATFORM_REVENUE_ACCOUNT
+	}
+	return &Journal{
+		platformAccount: platformAccount,
+		fundingAccount:  EXTERNAL_FUNDING_ACCOUNT,
+		payoutAccount:   EXTERNAL_PAYOUT_ACCOUNT,
+	}
+}
+
+func (j *Journal) PlatformAccount() string {
+	return j.platformAccount
+}
+
+// platformAccountFor returns the platform account credited by tx. It is
+// recorded on the transaction so that history does not move if the configured
+// account changes.
+func (j *Journal) platformAccountFor(tx types.Transaction) string {
+	if tx.Platform != nil && tx.Platform.Id != "" {
+		return tx.Platform.Id
+	}
+	return j.platformAccount
+}
+
+// IsSystemAccount reports whether account is owned by the ledger itself
+// rather than by a user.
+func (j *Journal) IsSystemAccount(account string) bool {
+	return account == j.platformAccount || account == j.fundingAccount || account == j.payoutAccount
+}
+
+// Postings returns the balanced set of postings that records tx.
+func (j *Journal) Postings(tx types.Transaction, postedAt time.Time) ([]types.Posting, error) {
+	var lines []line
+
+	switch tx.Type {
+	case types.DEPOSIT:
+		lines = []line{
+			{tx.Customer.Id, tx.Amount, types.PRINCIPAL_POSTING, false},
+			{j.fundingAccount, -tx.Amount, types.PRINCIPAL_POSTING, false},
+		}
+	case types.PURCHASE:
+		lines = []line{
+			{tx.Customer.Id, -tx.Amount, types.PRINCIPAL_POSTING, false},
+		}
+		if len(tx.Legs) == 0 {
+			lines = append(lines, line{tx.Restaurant.Id, tx.Amount, types.PRINCIPAL_POSTING, tx.Escrowed})
+		}
+		// Only the restaurant's legs are held in escrow.
+		for _, leg := range tx.Legs {
+			toRestaurant := leg.Payee.Id == tx.Restaurant.Id
+			lines = append(lines, line{leg.Payee.Id, leg.Amount, types.PRINCIPAL_POSTING, tx.Escrowed && toRestaurant})
+		}
+	case types.COMMISSION:
+		lines = []line{
+			{tx.Restaurant.Id, -tx.Amount, types.COMMISSION_POSTING, false},
+			{j.platformAccountFor(tx), tx.Amount, types.COMMISSION_POSTING, false},
+		}
+	case types.TRANSFER:
+		var recipient string
+		if tx.Recipient != nil {
+			recipient = tx.Recipient.Id
+		}
+		lines = []line{
+			{tx.Customer.Id, -tx.Amount, types.PRINCIPAL_POSTING, false},
+			{recipient, tx.Amount, types.PRINCIPAL_POSTING, false},
+		}
+	case types.PAYOUT:
+		lines = []line{
+			{tx.Restaurant.Id, -tx.Amount, types.PRINCIPAL_POSTING, false},
+			{j.payoutAccount, tx.Amount, types.PRINCIPAL_POSTING, false},
+		}
+	case types.REFUND:
+		lines = []line{
+			{tx.Customer.Id, tx.Amount, types.PRINCIPAL_POSTING, false},
+		}
+		if len(tx.Legs) == 0 {
+			lines = append(lines, line{tx.Restaurant.Id, -tx.Amount, types.PRINCIPAL_POSTING, tx.Escrowed})
+		}
+		// A refund gives back each leg of the purchase from where it was
+		// credited.
+		for _, leg := range tx.Legs {
+			toRestaurant := leg.Payee.Id == tx.Restaurant.Id
+			lines = append(lines, line{leg.Payee.Id, -leg.Amount, types.PRINCIPAL_POSTING, tx.Escrowed && toRestaurant})
+		}
+	case types.ESCROW_RELEASE:
+		lines = []line{
+			{tx.Restaurant.Id, -tx.Amount, types.PRINCIPAL_POSTING, true},
+			{tx.Restaurant.Id, tx.Amount, types.PRINCIPAL_POSTING, false},
+		}
+	case types.COMMISSION_REFUND:
+		lines = []line{
+			{tx.Restaurant.Id, tx.Amount, types.COMMISSION_POSTING, false},
+			{j.platformAccountFor(tx), -tx.Amount, types.COMMISSION_POSTING, false},
+		}
+	default:
+		return nil, fmt.Errorf("journal: %w: %s", types.ErrNoPostings, tx.Type)
+	}
+
+	return j.postings(tx, lines, postedAt)
+}
+
+// Reversal returns the postings of a REVERSAL: the exact inverse of the
+// postings of the transaction it reverses.
+func (j *Journal) Reversal(tx types.Transaction, reversed []types.Posting, postedAt time.Time) ([]types.Posting, error) {
+	if len(reversed) == 0 {
+		return nil, fmt.Errorf("journal: %w: transaction %s has no postings to reverse", types.ErrNotReversible, tx.RelatedTransaction)
+	}
+
+	lines := make([]line, 0, len(reversed))
+	for _, p := range reversed {
+		lines = append(lines, line{p.Account, -p.Amount, p.Kind, p.Escrow})
+	}
+
+	postings, err := j.postings(tx, lines, postedAt)
+	if err != nil {
+		return nil, err
+	}
+	// The commission goes back to the platform that earned it.
+	for i := range postings {
+		postings[i].Platform = reversed[i].Platform
+	}
+	return postings, nil
+}
+
+func (j *Journal) postings(tx types.Transaction, lines []line, postedAt time.Time) ([]types.Posting, error) {
+	platform := j.platformAccountFor(tx)
+
+	postings := make([]types.Posting, 0, len(lines))
+	for i, l := range lines {
+		postings = append(postings, types.Posting{
+			Id:            fmt.Sprintf("%s-%d", tx.Id, i),
+			TransactionId: tx.Id,
+			Account:       l.account,
+			Currency:      tx.Currency,
+			Amount:        l.amount,
+			Kind:          l.kind,
+			Escrow:        l.escrow,
+			Platform:      l.kind == types.COMMISSION_POSTING && l.account == platform,
+			PostedAt:      postedAt,
+		})
+	}
+
+	if err := Validate(postings); err != nil {
+		return nil, err
+	}
+	return postings, nil
+}
+
+// Validate checks that postings sum to zero in every currency, without the
+// sum wrapping around.
+func Validate(postings []types.Posting) error {
+	sums := map[types.Currency]types.Money{}
+	for _, p := range postings {
+		if p.Account == "" {
+			return fmt.Errorf("%w: posting %s has no account", types.ErrUnbalancedJournal, p.Id)
+		}
+		sum, err := sums[p.Currency].Add(p.Amount)
+		if err != nil {
+			return fmt.Errorf("%w: %w", types.ErrUnbalancedJournal, err)
+		}
+		sums[p.Currency] = sum
+	}
+
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%w: postings in %s sum to %d", types.ErrUnbalancedJournal, currency, sum)
+		}
+	}
+	return nil
+}
+
+type line struct {
+	account string
+	amount  types.Money
+	kind    types.PostingKind
+	escrow  bool
+}
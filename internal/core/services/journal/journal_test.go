@@ -0,0 +1,260 @@
+package journal
+
+import (
+	"errors"
+	"ledger-service/internal/core/types"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPostingsBalance(t *testing.T) {
+	j := New("")
+	customer := types.User{Id: "customer-1", Type: types.CUSTOMER}
+	restaurant := types.User{Id: "restaurant-1", Type: types.RESTAURANT}
+	courier := types.User{Id: "courier-1", Type: types.COURIER}
+
+	tests := []struct {
+		name string
+		tx   types.Transaction
+		// want is the net amount booked on each account, escrow included.
+		want map[string]types.Money
+		// escrow is the part of want booked to escrow.
+		escrow map[string]types.Money
+	}{
+		{
+			name: "deposit",
+			tx:   types.Transaction{Type: types.DEPOSIT, Amount: 1000, Customer: customer},
+			want: map[string]types.Money{"customer-1": 1000, EXTERNAL_FUNDING_ACCOUNT: -1000},
+		},
+		{
+			name: "purchase",
+			tx:   types.Transaction{Type: types.PURCHASE, Amount: 1000, Customer: customer, Restaurant: restaurant},
+			want: map[string]types.Money{"customer-1": -1000, "restaurant-1": 1000},
+		},
+		{
+			name:   "escrowed purchase",
+			tx:     types.Transaction{Type: types.PURCHASE, Amount: 1000, Customer: customer, Restaurant: restaurant, Escrowed: true},
+			want:   map[string]types.Money{"customer-1": -1000, "restaurant-1": 1000},
+			escrow: map[string]types.Money{"restaurant-1": 1000},
+		},
+		{
+			name: "escrowed purchase split into legs",
+			tx: types.Transaction{
+				Type: types.PURCHASE, Amount: 1500, Customer: customer, Restaurant: restaurant, Courier: &courier, Escrowed: true,
+				Legs: []types.Leg{
+					{Component: types.ITEMS, Payee: restaurant, Amount: 1200},
+					{Component: types.DELIVERY_FEE, Payee: courier, Amount: 200},
+					{Component: types.SERVICE_FEE, Payee: types.User{Id: PLATFORM_REVENUE_ACCOUNT}, Amount: 100},
+				},
+			},
+			want:   map[string]types.Money{"customer-1": -1500, "restaurant-1": 1200, "courier-1": 200, PLATFORM_REVENUE_ACCOUNT: 100},
+			escrow: map[string]types.Money{"restaurant-1": 1200},
+		},
+		{
+			name: "commission",
+			tx:   types.Transaction{Type: types.COMMISSION, Amount: 50, Restaurant: restaurant},
+			want: map[string]types.Money{"restaurant-1": -50, PLATFORM_REVENUE_ACCOUNT: 50},
+		},
+		{
+			name: "commission to the platform recorded on the transaction",
+			tx:   types.Transaction{Type: types.COMMISSION, Amount: 50, Restaurant: restaurant, Platform: &types.User{Id: "platform:old"}},
+			want: map[string]types.Money{"restaurant-1": -50, "platform:old": 50},
+		},
+		{
+			name: "transfer",
+			tx:   types.Transaction{Type: types.TRANSFER, Amount: 300, Customer: customer, Recipient: &types.User{Id: "customer-2"}},
+			want: map[string]types.Money{"customer-1": -300, "customer-2": 300},
+		},
+		{
+			name: "payout",
+			tx:   types.Transaction{Type: types.PAYOUT, Amount: 700, Restaurant: restaurant},
+			want: map[string]types.Money{"restaurant-1": -700, EXTERNAL_PAYOUT_ACCOUNT: 700},
+		},
+		{
+			name: "refund",
+			tx:   types.Transaction{Type: types.REFUND, Amount: 400, Customer: customer, Restaurant: restaurant},
+			want: map[string]types.Money{"customer-1": 400, "restaurant-1": -400},
+		},
+		{
+			name:   "escrowed refund",
+			tx:     types.Transaction{Type: types.REFUND, Amount: 400, Customer: customer, Restaurant: restaurant, Escrowed: true},
+			want:   map[string]types.Money{"customer-1": 400, "restaurant-1": -400},
+			escrow: map[string]types.Money{"restaurant-1": -400},
+		},
+		{
+			name: "escrowed refund split into legs",
+			tx: types.Transaction{
+				Type: types.REFUND, Amount: 500, Customer: customer, Restaurant: restaurant, Escrowed: true,
+				Legs: []types.Leg{
+					{Component: types.ITEMS, Payee: restaurant, Amount: 401},
+					{Component: types.DELIVERY_FEE, Payee: courier, Amount: 66},
+					{Component: types.SERVICE_FEE, Payee: types.User{Id: PLATFORM_REVENUE_ACCOUNT}, Amount: 33},
+				},
+			},
+			want:   map[string]types.Money{"customer-1": 500, "restaurant-1": -401, "courier-1": -66, PLATFORM_REVENUE_ACCOUNT: -33},
+			escrow: map[string]types.Money{"restaurant-1": -401},
+		},
+		{
+			name:   "escrow release",
+			tx:     types.Transaction{Type: types.ESCROW_RELEASE, Amount: 900, Restaurant: restaurant},
+			want:   map[string]types.Money{"restaurant-1": 0},
+			escrow: map[string]types.Money{"restaurant-1": -900},
+		},
+		{
+			name: "commission refund",
+			tx:   types.Transaction{Type: types.COMMISSION_REFUND, Amount: 20, Restaurant: restaurant},
+			want: map[string]types.Money{"restaurant-1": 20, PLATFORM_REVENUE_ACCOUNT: -20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.tx.Id = "tx-1"
+			tt.tx.Currency = "EUR"
+			postedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+			postings, err := j.Postings(tt.tx, postedAt)
+			if err != nil {
+				t.Fatalf("Postings returned error: %v", err)
+			}
+			if err := Validate(postings); err != nil {
+				t.Fatalf("postings do not balance: %v", err)
+			}
+
+			got := map[string]types.Money{}
+			escrow := map[string]types.Money{}
+			for _, p := range postings {
+				if p.TransactionId != "tx-1" || p.Currency != "EUR" || !p.PostedAt.Equal(postedAt) {
+					t.Errorf("posting %+v does not record the transaction", p)
+				}
+				got[p.Account] += p.Amount
+				if p.Escrow {
+					escrow[p.Account] += p.Amount
+				}
+			}
+			assertAmounts(t, "booked", got, tt.want)
+			assertAmounts(t, "escrowed", escrow, tt.escrow)
+		})
+	}
+}
+
+func TestCommissionPostingsMarkPlatform(t *testing.T) {
+	j := New("platform:new")
+	tests := []struct {
+		name     string
+		tx       types.Transaction
+		platform string
+	}{
+		{"configured platform", types.Transaction{Type: types.COMMISSION}, "platform:new"},
+		{"platform of the transaction", types.Transaction{Type: types.COMMISSION, Platform: &types.User{Id: "platform:old"}}, "platform:old"},
+		{"commission refund", types.Transaction{Type: types.COMMISSION_REFUND, Platform: &types.User{Id: "platform:old"}}, "platform:old"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.tx.Id = "commission-1"
+			tt.tx.Amount = 50
+			tt.tx.Currency = "EUR"
+			tt.tx.Restaurant = types.User{Id: "restaurant-1"}
+
+			postings, err := j.Postings(tt.tx, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			reversal, err := j.Reversal(types.Transaction{Id: "reversal-1", Currency: "EUR"}, postings, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, p := range append(postings, reversal...) {
+				if want := p.Account == tt.platform; p.Platform != want {
+					t.Errorf("posting %s on %s has Platform %t, want %t", p.Id, p.Account, p.Platform, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPostingsUnsupportedType(t *testing.T) {
+	_, err := New("").Postings(types.Transaction{Id: "hold-1", Type: types.HOLD, Amount: 100}, time.Now())
+	if !errors.Is(err, types.ErrNoPostings) {
+		t.Errorf("Postings(HOLD) error = %v, want ErrNoPostings", err)
+	}
+}
+
+func TestReversalInvertsPostings(t *testing.T) {
+	j := New("")
+	purchase := types.Transaction{
+		Id: "purchase-1", Type: types.PURCHASE, Amount: 1000, Currency: "EUR", Escrowed: true,
+		Customer:   types.User{Id: "customer-1"},
+		Restaurant: types.User{Id: "restaurant-1"},
+	}
+	original, err := j.Postings(purchase, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reversal := types.Transaction{Id: "reversal-1", Type: types.REVERSAL, Currency: "EUR", RelatedTransaction: "purchase-1"}
+	postings, err := j.Reversal(reversal, original, time.Now())
+	if err != nil {
+		t.Fatalf("Reversal returned error: %v", err)
+	}
+	if len(postings) != len(original) {
+		t.Fatalf("got %d postings, want %d", len(postings), len(original))
+	}
+	for i, p := range postings {
+		if p.Account != original[i].Account || p.Amount != -original[i].Amount || p.Escrow != original[i].Escrow {
+			t.Errorf("posting %d = %+v, want the inverse of %+v", i, p, original[i])
+		}
+	}
+
+	if _, err := j.Reversal(reversal, nil, time.Now()); !errors.Is(err, types.ErrNotReversible) {
+		t.Errorf("Reversal without postings error = %v, want ErrNotReversible", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		postings []types.Posting
+		wantErr  bool
+	}{
+		{"empty", nil, false},
+		{"balanced", []types.Posting{{Account: "a", Currency: "EUR", Amount: 5}, {Account: "b", Currency: "EUR", Amount: -5}}, false},
+		{"unbalanced", []types.Posting{{Account: "a", Currency: "EUR", Amount: 5}, {Account: "b", Currency: "EUR", Amount: -4}}, true},
+		{"balanced in total but not per currency", []types.Posting{{Account: "a", Currency: "EUR", Amount: 5}, {Account: "b", Currency: "USD", Amount: -5}}, true},
+		{"missing account", []types.Posting{{Account: "", Currency: "EUR", Amount: 0}}, true},
+		{"sum wraps around to zero", []types.Posting{
+			{Account: "a", Currency: "EUR", Amount: math.MaxInt64},
+			{Account: "b", Currency: "EUR", Amount: math.MaxInt64},
+			{Account: "c", Currency: "EUR", Amount: 2},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.postings)
+			if tt.wantErr && !errors.Is(err, types.ErrUnbalancedJournal) {
+				t.Errorf("Validate() error = %v, want ErrUnbalancedJournal", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Validate() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func assertAmounts(t *testing.T, what string, got, want map[string]types.Money) {
+	t.Helper()
+	for account, amount := range want {
+		if got[account] != amount {
+			t.Errorf("%s on %s = %d, want %d", what, account, got[account], amount)
+		}
+	}
+	for account, amount := range got {
+		if _, ok := want[account]; !ok && amount != 0 {
+			t.Errorf("unexpected %d %s on %s", amount, what, account)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"time"
+)
+
+// GetCommissionPolicies returns every stored policy, the default one first
+// if it is stored.
+func (s *Service) GetCommissionPolicies(ctx context.Context) ([]types.CommissionPolicy, error) {
+	return s.commissionPolicyRepo.GetAll(ctx)
+}
+
+// GetDefaultCommissionPolicy returns the policy applied to restaurants
+// without one of their own, falling back to COMMISSION_RATE if none is
+// stored.
+func (s *Service) GetDefaultCommissionPolicy(ctx context.Context) (types.CommissionPolicy, error) {
+	policy, found, err := s.commissionPolicyRepo.Get(ctx, "")
+	if err != nil {
+		return types.CommissionPolicy{}, err
+	}
+	if !found {
+		return types.CommissionPolicy{Rate: COMMISSION_RATE}, nil
+	}
+	return policy, nil
+}
+
+func (s *Service) GetCommissionPolicy(ctx context.Context, restaurantId string) (types.CommissionPolicy, error) {
+	policy, found, err := s.commissionPolicyRepo.Get(ctx, restaurantId)
+	if err != nil {
+		return types.CommissionPolicy{}, err
+	}
+	if !found {
+		return types.CommissionPolicy{}, fmt.Errorf("%w: restaurant %s", types.ErrCommissionPolicyNotFound, restaurantId)
+	}
+	return policy, nil
+}
+
+// SetCommissionPolicy stores the restaurant's commission policy, or the
+// default policy when restaurantId is empty. It applies to purchases posted
+// from now on, evaluated at their creation time.
+func (s *Service) SetCommissionPolicy(ctx context.Context, restaurantId string, rate types.Rate, schedule []types.CommissionRule) (types.CommissionPolicy, error) {
+	policy := types.CommissionPolicy{
+		RestaurantId: restaurantId,
+		Rate:         rate,
+		Schedule:     schedule,
+		UpdatedAt:    time.Now(),
+	}
+	if err := policy.Validate(); err != nil {
+		return types.CommissionPolicy{}, err
+	}
+
+	return s.commissionPolicyRepo.Upsert(ctx, policy)
+}
+
+// DeleteCommissionPolicy removes the restaurant's policy so that the default
+// applies to it again.
+func (s *Service) DeleteCommissionPolicy(ctx context.Context, restaurantId string) error {
+	deleted, err := s.commissionPolicyRepo.Delete(ctx, restaurantId)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: restaurant %s", types.ErrCommissionPolicyNotFound, restaurantId)
+	}
+	return nil
+}
+
+// commissionPolicy returns the policy charged on the restaurant's purchases:
+// its own if it has one, the default policy otherwise.
+func (s *Service) commissionPolicy(ctx context.Context, restaurantId string) (types.CommissionPolicy, error) {
+	policy, found, err := s.commissionPolicyRepo.Get(ctx, restaurantId)
+	if err != nil {
+		return types.CommissionPolicy{}, err
+	}
+	if found {
+		return policy, nil
+	}
+	return s.GetDefaultCommissionPolicy(ctx)
+}
+
+// commission computes the commission owed on a purchase under the schedule
+// rule in effect when it was created, so that backdated and replayed
+// purchases are charged what they would have been at the time. It returns
+// the fee and the rate it was computed with. Only the purchase's
+// commissionable legs are charged.
+func (s *Service) commission(ctx context.Context, tx types.Transaction) (types.Money, types.Rate, error) {
+	policy, err := s.commissionPolicy(ctx, tx.Restaurant.Id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rule, found := policy.RuleAt(tx.CreatedAt, tx.Currency)
+	if !found {
+		fee, err := tx.CommissionBase().MulRate(policy.Rate, COMMISSION_ROUNDING)
+		return fee, policy.Rate, err
+	}
+
+	var volume types.Money
+	if rule.Tiered() {
+		created := tx.CreatedAt.UTC()
+		monthStart := time.Date(created.Year(), created.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+		volume, err = s.transactionRepo.PurchaseVolume(ctx, tx.Restaurant.Id, tx.Currency, monthStart, tx.CreatedAt)
+		if err != nil {
+			return 0, 0, fmt.Errorf("monthly purchase volume: %w", err)
+		}
+	}
+
+	rate := rule.Tier(volume).Rate
+	fee, err := rule.Fee(tx.CommissionBase(), rate, COMMISSION_ROUNDING)
+	return fee, rate, err
+}
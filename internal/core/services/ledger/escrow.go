@@ -0,0 +1,172 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"time"
+)
+
+// bookEscrow decides, when tx is posted, whether its restaurant side is booked
+// against the restaurant's escrow. Purchase proceeds always go to escrow until
+// delivery or the clearing period ends, while the legs of other parties are
+// credited right away; the restaurant's part of a refund is taken from the
+// purchase's escrow while it is still held. It is expected to run inside the transaction
+// that applies tx, before its postings are made.
+func (s *Service) bookEscrow(ctx context.Context, tx *types.Transaction, postedAt time.Time) error {
+	switch tx.Type {
+	case types.PURCHASE:
+		releaseAt := postedAt.Add(s.config.EscrowClearingPeriod)
+		if err := s.transactionRepo.EscrowProceeds(ctx, tx.Id, tx.RestaurantShare(), releaseAt); err != nil {
+			return err
+		}
+		tx.Escrowed = true
+	case types.REFUND:
+		share := tx.RestaurantShare()
+		if share == 0 {
+			return nil
+		}
+		debited, err := s.transactionRepo.DebitEscrow(ctx, tx.RelatedTransaction, share)
+		if err != nil || !debited {
+			return err
+		}
+		// Reversing the refund puts it back where it was taken from.
+		if err := s.transactionRepo.MarkEscrowed(ctx, tx.Id); err != nil {
+			return err
+		}
+		tx.Escrowed = true
+	}
+	return nil
+}
+
+// MarkDelivered records that the order paid by the purchase was delivered and
+// releases its proceeds from the restaurant's escrow to its balance.
+func (s *Service) MarkDelivered(ctx context.Context, purchaseId string) (types.Transaction, error) {
+	purchase, err := s.transactionRepo.GetById(ctx, purchaseId)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	if purchase.Type != types.PURCHASE || purchase.EscrowStatus != types.ESCROW_HELD {
+		return types.Transaction{}, fmt.Errorf("%w: transaction %s", types.ErrEscrowNotHeld, purchaseId)
+	}
+
+	deliveredAt := time.Now()
+	if err := s.releaseEscrow(ctx, purchaseId, &deliveredAt); err != nil {
+		return types.Transaction{}, err
+	}
+
+	return s.transactionRepo.GetById(ctx, purchaseId)
+}
+
+// releaseEscrow moves what is left of a purchase's proceeds in escrow to the
+// restaurant's balance with an ESCROW_RELEASE transaction.
+func (s *Service) releaseEscrow(ctx context.Context, purchaseId string, deliveredAt *time.Time) error {
+	return s.transactionRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		purchase, held, err := s.transactionRepo.CloseEscrow(ctx, purchaseId, deliveredAt)
+		if err != nil {
+			return err
+		}
+		if !held {
+			return fmt.Errorf("%w: transaction %s", types.ErrEscrowNotHeld, purchaseId)
+		}
+
+		// Proceeds refunded in full leave nothing to release.
+		if purchase.EscrowAmount <= 0 {
+			return nil
+		}
+
+		postedAt := time.Now()
+		release := types.Transaction{
+			Type:               types.ESCROW_RELEASE,
+			Status:             types.POSTED,
+			Amount:             purchase.EscrowAmount,
+			Currency:           purchase.Currency,
+			Restaurant:         purchase.Restaurant,
+			RelatedTransaction: purchase.Id,
+			CreatedAt:          postedAt,
+			PostedAt:           &postedAt,
+		}
+
+		id, err := s.transactionRepo.Save(ctx, release)
+		if err != nil {
+			return err
+		}
+		release.Id = id
+
+		return s.updateBalances(ctx, release, postedAt)
+	})
+}
+
+// reverseEscrow adjusts the inverse postings of a reversed purchase or refund
+// that was booked against escrow. While the purchase's proceeds are still
+// held, reversing the purchase takes them back out of escrow, which is
+// closed, and reversing a refund puts what it took back in; the reversal is
+// then marked escrowed. Once the proceeds were released, the inverse postings
+// go to the restaurant's balance instead.
+func (s *Service) reverseEscrow(ctx context.Context, reversal, original types.Transaction, reversed []types.Posting) error {
+	var held bool
+	var err error
+	switch original.Type {
+	case types.PURCHASE:
+		_, held, err = s.transactionRepo.CloseEscrow(ctx, original.Id, nil)
+	case types.REFUND:
+		var taken types.Money
+		for _, p := range reversed {
+			if p.Escrow {
+				taken -= p.Amount
+			}
+		}
+		held, err = s.transactionRepo.RestoreEscrow(ctx, original.RelatedTransaction, taken)
+	}
+	if err != nil {
+		return err
+	}
+	if held {
+		return s.transactionRepo.MarkEscrowed(ctx, reversal.Id)
+	}
+
+	for i := range reversed {
+		reversed[i].Escrow = false
+	}
+	return nil
+}
+
+// clearEscrow releases proceeds whose clearing period passed, looking for them
+// every Config.EscrowSweepInterval.
+func (s *Service) clearEscrow() {
+	ticker := time.NewTicker(s.config.EscrowSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweepClearedEscrow()
+		}
+	}
+}
+
+func (s *Service) sweepClearedEscrow() {
+	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+	defer cancel()
+
+	purchases, err := s.transactionRepo.GetEscrowDue(ctx, time.Now())
+	if err != nil {
+		s.logger.Error("Looking up escrow due for release failed", "error", err.Error())
+		return
+	}
+
+	for _, purchase := range purchases {
+		// Proceeds released on delivery or reversed concurrently are not
+		// held anymore.
+		if err := s.releaseEscrow(ctx, purchase.Id, nil); err != nil && !errors.Is(err, types.ErrEscrowNotHeld) {
+			s.logger.Error("Releasing escrow failed",
+				"error", err.Error(),
+				"transaction_id", purchase.Id,
+			)
+		}
+	}
+}
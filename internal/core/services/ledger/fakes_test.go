@@ -0,0 +1,173 @@
+package ledger
+
+import (
+	"context"
+	"ledger-service/internal/core/interfaces"
+	"ledger-service/internal/core/types"
+	"slices"
+	"time"
+)
+
+// The fakes below keep just enough state in memory for the tests of this
+// package; any method they do not implement panics.
+
+type fakeTransactions struct {
+	interfaces.TransactionRepository
+	transactions []types.Transaction
+}
+
+// add records tx unless a transaction with its id was recorded already.
+func (f *fakeTransactions) add(tx types.Transaction) {
+	if !slices.ContainsFunc(f.transactions, func(t types.Transaction) bool { return t.Id == tx.Id }) {
+		f.transactions = append(f.transactions, tx)
+	}
+}
+
+func (f *fakeTransactions) GetById(ctx context.Context, id string) (types.Transaction, error) {
+	for _, tx := range f.transactions {
+		if tx.Id == id {
+			return tx, nil
+		}
+	}
+	return types.Transaction{}, types.ErrTransactionNotFound
+}
+
+func (f *fakeTransactions) GetByIds(ctx context.Context, ids []string) ([]types.Transaction, error) {
+	transactions := []types.Transaction{}
+	for _, tx := range f.transactions {
+		if slices.Contains(ids, tx.Id) {
+			transactions = append(transactions, tx)
+		}
+	}
+	return transactions, nil
+}
+
+func (f *fakeTransactions) ForEachPosted(ctx context.Context, fn func(types.Transaction) error) error {
+	for _, tx := range f.transactions {
+		if tx.Status != types.POSTED || tx.Type == types.HOLD {
+			continue
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fakePostings serves the postings of the journal in the order they were
+// booked.
+type fakePostings struct {
+	interfaces.PostingRepository
+	postings []types.Posting
+}
+
+func (f *fakePostings) SaveMany(ctx context.Context, postings []types.Posting) error {
+	f.postings = append(f.postings, postings...)
+	return nil
+}
+
+func (f *fakePostings) GetHistory(ctx context.Context, account string, currency types.Currency, from, to time.Time, after *types.Cursor, limit int) ([]types.Posting, error) {
+	start := 0
+	if after != nil {
+		start = slices.IndexFunc(f.postings, func(p types.Posting) bool { return p.Id == after.Id }) + 1
+	}
+
+	page := []types.Posting{}
+	for _, p := range f.postings[start:] {
+		if p.Account == account && p.Currency == currency && !p.PostedAt.Before(from) && p.PostedAt.Before(to) && len(page) < limit {
+			page = append(page, p)
+		}
+	}
+	return page, nil
+}
+
+func (f *fakePostings) Totals(ctx context.Context, account string, currency types.Currency, from, to time.Time) ([]types.BalanceSnapshot, error) {
+	totals := []types.BalanceSnapshot{}
+	for _, p := range f.postings {
+		if account != "" && (p.Account != account || p.Currency != currency) {
+			continue
+		}
+		if (!from.IsZero() && !p.PostedAt.After(from)) || (!to.IsZero() && p.PostedAt.After(to)) {
+			continue
+		}
+
+		i := slices.IndexFunc(totals, func(t types.BalanceSnapshot) bool { return t.Account == p.Account && t.Currency == p.Currency })
+		if i < 0 {
+			totals = append(totals, types.BalanceSnapshot{Account: p.Account, Currency: p.Currency})
+			i = len(totals) - 1
+		}
+		balance := book(types.Balance{}, p)
+		totals[i] = totals[i].Add(types.BalanceSnapshot{Amount: balance.Amount, Escrow: balance.Escrow, Commission: balance.TotalCommission, Postings: 1})
+	}
+	return totals, nil
+}
+
+type fakeSnapshots struct {
+	interfaces.BalanceSnapshotRepository
+}
+
+func (fakeSnapshots) Latest(ctx context.Context, account string, currency types.Currency, asOf time.Time) (types.BalanceSnapshot, bool, error) {
+	return types.BalanceSnapshot{}, false, nil
+}
+
+type fakeBalances struct {
+	interfaces.BalanceRepository
+	balances []types.Balance
+}
+
+func (f *fakeBalances) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (f *fakeBalances) GetBalance(ctx context.Context, userId string, currency types.Currency) (types.Balance, error) {
+	return *f.wallet(userId, currency), nil
+}
+
+func (f *fakeBalances) ForEach(ctx context.Context, fn func(types.Balance) error) error {
+	for _, balance := range f.balances {
+		if err := fn(balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeBalances) UpdateBalance(ctx context.Context, userId string, currency types.Currency, amount types.Money) (types.Balance, error) {
+	wallet := f.wallet(userId, currency)
+	wallet.Amount += amount
+	wallet.Sequence++
+	return *wallet, nil
+}
+
+func (f *fakeBalances) UpdateEscrow(ctx context.Context, userId string, currency types.Currency, amount types.Money) (types.Balance, error) {
+	wallet := f.wallet(userId, currency)
+	wallet.Escrow += amount
+	wallet.Sequence++
+	return *wallet, nil
+}
+
+func (f *fakeBalances) UpdateTotalCommission(ctx context.Context, userId string, currency types.Currency, amount types.Money) error {
+	f.wallet(userId, currency).TotalCommission += amount
+	return nil
+}
+
+func (f *fakeBalances) UpdateReserved(ctx context.Context, userId string, currency types.Currency, amount types.Money) error {
+	f.wallet(userId, currency).Reserved += amount
+	return nil
+}
+
+func (f *fakeBalances) SetOverdraftLimit(ctx context.Context, userId string, currency types.Currency, limit types.Money) (types.Balance, error) {
+	wallet := f.wallet(userId, currency)
+	wallet.OverdraftLimit = limit
+	return *wallet, nil
+}
+
+// wallet returns the stored wallet, creating it if needed.
+func (f *fakeBalances) wallet(userId string, currency types.Currency) *types.Balance {
+	i := slices.IndexFunc(f.balances, func(b types.Balance) bool { return b.UserId == userId && b.Currency == currency })
+	if i < 0 {
+		f.balances = append(f.balances, types.Balance{UserId: userId, Currency: currency})
+		i = len(f.balances) - 1
+	}
+	return &f.balances[i]
+}
@@ -0,0 +1,41 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"time"
+)
+
+// GetBalanceHistory returns a page of the changes to the user's balances:
+// their postings booked in [from, to), oldest first, each with the balance it
+// resulted in. The page starts after cursor if it is set; the returned cursor
+// is empty on the last page.
+func (s *Service) GetBalanceHistory(ctx context.Context, userId string, currency types.Currency, from, to time.Time, cursor string, limit int) ([]types.Posting, string, error) {
+	if currency != "" && !currency.Valid() {
+		return nil, "", fmt.Errorf("%w: %s", types.ErrUnsupportedCurrency, currency)
+	}
+
+	var after *types.Cursor
+	if cursor != "" {
+		parsed, err := types.ParseCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &parsed
+	}
+
+	// One more than asked tells whether there is a next page.
+	postings, err := s.postingRepo.GetHistory(ctx, userId, currency, from, to, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(postings) <= limit {
+		return postings, "", nil
+	}
+
+	postings = postings[:limit]
+	last := postings[limit-1]
+	return postings, types.Cursor{At: last.PostedAt, Id: last.Id}.Encode(), nil
+}
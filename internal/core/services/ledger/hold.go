@@ -0,0 +1,204 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"time"
+)
+
+// PlaceHold sets amount aside on the customer's balance for an order at the
+// restaurant. It passes the same funds check as a purchase and lowers the
+// available balance but not the ledger balance. The hold lasts until it is
+// captured or released, or expires after Config.HoldTTL.
+func (s *Service) PlaceHold(ctx context.Context, customerId, restaurantId string, amount types.Money, currency types.Currency, idempotencyKey string) (types.Transaction, bool, error) {
+	hold := types.Transaction{
+		Type:     types.HOLD,
+		Amount:   amount,
+		Currency: currency,
+		Customer: types.User{
+			Id:   customerId,
+			Type: types.CUSTOMER,
+		},
+		Restaurant: types.User{
+			Id:   restaurantId,
+			Type: types.RESTAURANT,
+		},
+		CreatedAt: time.Now(),
+	}
+
+	return s.SaveTransaction(ctx, hold, idempotencyKey)
+}
+
+// saveHold stores a hold whose funds were reserved. It has no balance effect
+// to apply, so it is POSTED right away and gets no outbox entry.
+func (s *Service) saveHold(ctx context.Context, hold types.Transaction) (types.Transaction, error) {
+	postedAt := time.Now()
+	expiresAt := hold.CreatedAt.Add(s.config.HoldTTL)
+
+	hold.Status = types.POSTED
+	hold.PostedAt = &postedAt
+	hold.HoldStatus = types.HOLD_ACTIVE
+	hold.ExpiresAt = &expiresAt
+
+	id, err := s.transactionRepo.Save(ctx, hold)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	hold.Id = id
+
+	return hold, nil
+}
+
+// CaptureHold turns an active hold into a PURCHASE of amount, which is
+// applied and charged commission like any other purchase. A zero amount
+// captures the whole hold; whatever is not captured is released. When
+// idempotencyKey is set, retrying the same capture with the same key returns
+// the purchase created by the first call with replayed set.
+func (s *Service) CaptureHold(ctx context.Context, holdId string, amount types.Money, idempotencyKey string) (types.Transaction, bool, error) {
+	if idempotencyKey == "" {
+		purchase, err := s.captureHold(ctx, holdId, amount)
+		return purchase, false, err
+	}
+
+	capture := types.Transaction{Type: types.PURCHASE, Amount: amount, RelatedTransaction: holdId}
+	return s.idempotent(ctx, idempotencyKey, requestHash(capture), func(ctx context.Context) (types.Transaction, error) {
+		return s.captureHold(ctx, holdId, amount)
+	})
+}
+
+func (s *Service) captureHold(ctx context.Context, holdId string, amount types.Money) (types.Transaction, error) {
+	var purchase types.Transaction
+	err := s.transactionRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		hold, err := s.getHold(ctx, holdId)
+		if err != nil {
+			return err
+		}
+
+		if hold.HoldStatus != types.HOLD_ACTIVE {
+			return fmt.Errorf("%w: hold %s is %s", types.ErrHoldNotActive, hold.Id, hold.HoldStatus)
+		}
+		if hold.ExpiresAt != nil && !time.Now().Before(*hold.ExpiresAt) {
+			return fmt.Errorf("%w: hold %s expired", types.ErrHoldNotActive, hold.Id)
+		}
+
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount > hold.Amount {
+			return fmt.Errorf("%w: %d of %d", types.ErrCaptureExceedsHold, amount, hold.Amount)
+		}
+
+		resolved, err := s.transactionRepo.ResolveHold(ctx, hold.Id, types.HOLD_CAPTURED)
+		if err != nil {
+			return err
+		}
+		if !resolved {
+			return fmt.Errorf("%w: hold %s", types.ErrHoldNotActive, hold.Id)
+		}
+
+		// The purchase keeps the captured part of the hold's reservation
+		// until it is applied.
+		if err := s.balanceRepo.UpdateReserved(ctx, hold.Customer.Id, hold.Currency, amount-hold.ReservedAmount); err != nil {
+			return err
+		}
+
+		purchase, err = s.saveWithOutbox(ctx, types.Transaction{
+			Type:               types.PURCHASE,
+			Amount:             amount,
+			Currency:           hold.Currency,
+			Customer:           hold.Customer,
+			Restaurant:         hold.Restaurant,
+			RelatedTransaction: hold.Id,
+			ReservedAmount:     amount,
+			CreatedAt:          time.Now(),
+		})
+		return err
+	})
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	s.wakeRelay()
+
+	return purchase, nil
+}
+
+// ReleaseHold frees the funds of an active hold.
+func (s *Service) ReleaseHold(ctx context.Context, holdId string) (types.Transaction, error) {
+	hold, err := s.getHold(ctx, holdId)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	if err := s.resolveHold(ctx, hold, types.HOLD_RELEASED); err != nil {
+		return types.Transaction{}, err
+	}
+
+	return s.transactionRepo.GetById(ctx, holdId)
+}
+
+func (s *Service) getHold(ctx context.Context, holdId string) (types.Transaction, error) {
+	hold, err := s.transactionRepo.GetById(ctx, holdId)
+	if err != nil && !errors.Is(err, types.ErrTransactionNotFound) {
+		return types.Transaction{}, err
+	}
+
+	if err != nil || hold.Type != types.HOLD {
+		return types.Transaction{}, fmt.Errorf("%w: %s", types.ErrHoldNotFound, holdId)
+	}
+	return hold, nil
+}
+
+// resolveHold moves an active hold to the to status and releases its funds.
+func (s *Service) resolveHold(ctx context.Context, hold types.Transaction, to types.HoldStatus) error {
+	return s.transactionRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		resolved, err := s.transactionRepo.ResolveHold(ctx, hold.Id, to)
+		if err != nil {
+			return err
+		}
+		if !resolved {
+			return fmt.Errorf("%w: hold %s", types.ErrHoldNotActive, hold.Id)
+		}
+
+		return s.balanceRepo.UpdateReserved(ctx, hold.Customer.Id, hold.Currency, -hold.ReservedAmount)
+	})
+}
+
+// expireHolds releases holds whose TTL passed, looking for them every
+// Config.HoldSweepInterval.
+func (s *Service) expireHolds() {
+	ticker := time.NewTicker(s.config.HoldSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweepExpiredHolds()
+		}
+	}
+}
+
+func (s *Service) sweepExpiredHolds() {
+	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+	defer cancel()
+
+	holds, err := s.transactionRepo.GetExpiredHolds(ctx, time.Now())
+	if err != nil {
+		s.logger.Error("Looking up expired holds failed", "error", err.Error())
+		return
+	}
+
+	for _, hold := range holds {
+		// A hold captured or released concurrently is not active anymore.
+		if err := s.resolveHold(ctx, hold, types.HOLD_EXPIRED); err != nil && !errors.Is(err, types.ErrHoldNotActive) {
+			s.logger.Error("Expiring hold failed",
+				"error", err.Error(),
+				"transaction_id", hold.Id,
+			)
+		}
+	}
+}
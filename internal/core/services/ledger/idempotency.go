@@ -0,0 +1,97 @@
+package ledger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"time"
+)
+
+func (s *Service) saveTransactionIdempotent(ctx context.Context, transaction types.Transaction, key string) (types.Transaction, bool, error) {
+	return s.idempotent(ctx, key, requestHash(transaction), func(ctx context.Context) (types.Transaction, error) {
+		return s.saveTransaction(ctx, transaction)
+	})
+}
+
+// idempotent runs create, which makes a transaction, and stores key with the
+// request's hash in the same database transaction. If key was stored before,
+// the transaction it created is returned with true instead.
+func (s *Service) idempotent(ctx context.Context, key, hash string, create func(ctx context.Context) (types.Transaction, error)) (types.Transaction, bool, error) {
+	if original, ok, err := s.replay(ctx, key, hash); err != nil || ok {
+		return original, ok, err
+	}
+
+	var saved types.Transaction
+	err := s.transactionRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		saved, err = create(ctx)
+		if err != nil {
+			return err
+		}
+
+		return s.idempotencyRepo.Create(ctx, types.IdempotencyRecord{
+			Key:           key,
+			RequestHash:   hash,
+			TransactionId: saved.Id,
+			CreatedAt:     time.Now(),
+		})
+	})
+
+	if errors.Is(err, types.ErrIdempotencyKeyExists) {
+		// A concurrent request with the same key committed first.
+		original, ok, err := s.replay(ctx, key, hash)
+		if err == nil && !ok {
+			err = types.ErrIdempotencyKeyInProgress
+		}
+		return original, ok, err
+	}
+	if err != nil {
+		return types.Transaction{}, false, err
+	}
+
+	return saved, false, nil
+}
+
+// replay looks up the transaction previously created with key. It fails with
+// types.ErrIdempotencyKeyReused if the key was used for a different request.
+func (s *Service) replay(ctx context.Context, key, hash string) (types.Transaction, bool, error) {
+	record, ok, err := s.idempotencyRepo.Get(ctx, key)
+	if err != nil || !ok {
+		return types.Transaction{}, false, err
+	}
+
+	if record.RequestHash != hash {
+		return types.Transaction{}, false, types.ErrIdempotencyKeyReused
+	}
+
+	original, err := s.transactionRepo.GetById(ctx, record.TransactionId)
+	if err != nil {
+		return types.Transaction{}, false, err
+	}
+	return original, true, nil
+}
+
+// requestHash fingerprints the client supplied parts of a transaction, so a
+// key reused with a different body can be told apart from a genuine retry.
+func requestHash(t types.Transaction) string {
+	fingerprint := fmt.Sprintf("%s|%s|%s|%s|%d|%s",
+		t.Type, t.Customer.Id, t.Restaurant.Id, t.Currency, t.Amount, t.RelatedTransaction)
+	if t.Recipient != nil && t.Recipient.Id != "" {
+		fingerprint += "|" + t.Recipient.Id
+	}
+	if t.Reason != "" || t.Operator != "" {
+		fingerprint += fmt.Sprintf("|%q|%q", t.Reason, t.Operator)
+	}
+	if t.Order != nil {
+		courierId := ""
+		if t.Courier != nil {
+			courierId = t.Courier.Id
+		}
+		fingerprint += fmt.Sprintf("|%v|%s", *t.Order, courierId)
+	}
+	sum := sha256.Sum256([]byte(fingerprint))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,139 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/internal/core/interfaces"
+	"ledger-service/internal/core/types"
+	"testing"
+	"time"
+)
+
+func TestRequestHash(t *testing.T) {
+	purchase := func() types.Transaction {
+		return types.Transaction{
+			Type:       types.PURCHASE,
+			Amount:     2500,
+			Currency:   "EUR",
+			Customer:   types.User{Id: "customer-1", Type: types.CUSTOMER},
+			Restaurant: types.User{Id: "restaurant-1", Type: types.RESTAURANT},
+			Order: &types.Order{
+				Items:       []types.LineItem{{Name: "Pizza", Quantity: 2, UnitPrice: 1000}},
+				DeliveryFee: 300,
+				Tip:         200,
+			},
+			Courier: &types.User{Id: "courier-1", Type: types.COURIER},
+		}
+	}
+	base := requestHash(purchase())
+
+	same := []struct {
+		name   string
+		modify func(*types.Transaction)
+	}{
+		{"identical request", func(*types.Transaction) {}},
+		{"server assigned id", func(t *types.Transaction) { t.Id = "5f1d7c" }},
+		{"server assigned status", func(t *types.Transaction) { t.Status = types.POSTED }},
+		{"server assigned time", func(t *types.Transaction) { t.CreatedAt = time.Now() }},
+		{"user types", func(t *types.Transaction) { t.Customer.Type = "" }},
+	}
+	for _, tt := range same {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := purchase()
+			tt.modify(&tx)
+			if got := requestHash(tx); got != base {
+				t.Errorf("requestHash changed: %s, want %s", got, base)
+			}
+		})
+	}
+
+	different := []struct {
+		name   string
+		modify func(*types.Transaction)
+	}{
+		{"type", func(t *types.Transaction) { t.Type = types.DEPOSIT }},
+		{"amount", func(t *types.Transaction) { t.Amount++ }},
+		{"currency", func(t *types.Transaction) { t.Currency = "USD" }},
+		{"customer", func(t *types.Transaction) { t.Customer.Id = "customer-2" }},
+		{"restaurant", func(t *types.Transaction) { t.Restaurant.Id = "restaurant-2" }},
+		{"related transaction", func(t *types.Transaction) { t.RelatedTransaction = "purchase-1" }},
+		{"recipient", func(t *types.Transaction) { t.Recipient = &types.User{Id: "customer-2"} }},
+		{"courier", func(t *types.Transaction) { t.Courier.Id = "courier-2" }},
+		{"line item", func(t *types.Transaction) { t.Order.Items[0].Quantity = 3 }},
+		{"tip", func(t *types.Transaction) { t.Order.Tip = 0 }},
+		{"no order", func(t *types.Transaction) { t.Order = nil }},
+		{"reason", func(t *types.Transaction) { t.Reason = "duplicate charge" }},
+		{"operator", func(t *types.Transaction) { t.Operator = "support-1" }},
+	}
+	for _, tt := range different {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := purchase()
+			tt.modify(&tx)
+			if got := requestHash(tx); got == base {
+				t.Errorf("requestHash did not change when the %s changed", tt.name)
+			}
+		})
+	}
+
+}
+
+type fakeIdempotency struct {
+	interfaces.IdempotencyRepository
+	records []types.IdempotencyRecord
+}
+
+func (f *fakeIdempotency) Get(ctx context.Context, key string) (types.IdempotencyRecord, bool, error) {
+	for _, record := range f.records {
+		if record.Key == key {
+			return record, true, nil
+		}
+	}
+	return types.IdempotencyRecord{}, false, nil
+}
+
+func TestReverseTransactionKeyReuse(t *testing.T) {
+	purchase := types.Transaction{
+		Id: "purchase-1", Type: types.PURCHASE, Status: types.POSTED, Amount: 1000, Currency: "EUR",
+		Customer:   types.User{Id: "customer-1"},
+		Restaurant: types.User{Id: "restaurant-1"},
+	}
+	reversal := types.Transaction{
+		Id: "reversal-1", Type: types.REVERSAL, Status: types.POSTED, Amount: 1000, Currency: "EUR",
+		Customer:           purchase.Customer,
+		Restaurant:         purchase.Restaurant,
+		RelatedTransaction: purchase.Id,
+		Reason:             "duplicate charge",
+		Operator:           "support-1",
+	}
+	s := NewService(Repositories{
+		Transactions: &fakeTransactions{transactions: []types.Transaction{purchase, reversal}},
+		Idempotency: &fakeIdempotency{records: []types.IdempotencyRecord{
+			{Key: "key-1", RequestHash: requestHash(reversal), TransactionId: reversal.Id},
+		}},
+	}, nil, Config{})
+
+	tests := []struct {
+		name             string
+		reason, operator string
+		wantErr          error
+	}{
+		{"same request", "duplicate charge", "support-1", nil},
+		{"other reason", "customer complaint", "support-1", types.ErrIdempotencyKeyReused},
+		{"other operator", "duplicate charge", "support-2", types.ErrIdempotencyKeyReused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replayed, err := s.ReverseTransaction(context.Background(), purchase.Id, tt.reason, tt.operator, "key-1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ReverseTransaction error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !replayed || got.Id != reversal.Id {
+				t.Errorf("ReverseTransaction = %s, %t, %v, want the replayed %s", got.Id, replayed, err, reversal.Id)
+			}
+		})
+	}
+}
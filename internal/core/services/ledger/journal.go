@@ -0,0 +1,118 @@
+package ledger
+
+import (
+	"context"
+	"ledger-service/internal/core/types"
+	"slices"
+)
+
+// BackfillJournal records postings for transactions that were posted before
+// the journal existed. User balances already include those transactions, so
+// only the postings against system accounts are applied to balances.
+// Transactions that already have postings are skipped, so it can be resumed.
+func (s *Service) BackfillJournal(ctx context.Context) error {
+	return s.transactionRepo.ForEachPosted(ctx, func(tx types.Transaction) error {
+		return s.balanceRepo.WithTransaction(ctx, func(ctx context.Context) error {
+			existing, err := s.postingRepo.GetForTransaction(ctx, tx.Id)
+			if err != nil || len(existing) > 0 {
+				return err
+			}
+
+			postedAt := tx.CreatedAt
+			if tx.PostedAt != nil {
+				postedAt = *tx.PostedAt
+			}
+
+			postings, err := s.journal.Postings(tx, postedAt)
+			if err != nil {
+				return err
+			}
+
+			for i := range postings {
+				if !s.journal.IsSystemAccount(postings[i].Account) {
+					continue
+				}
+				if err := s.applyPosting(ctx, &postings[i]); err != nil {
+					return err
+				}
+			}
+
+			return s.postingRepo.SaveMany(ctx, postings)
+		})
+	})
+}
+
+// BackfillEscrowFlags marks the refunds and reversals that were booked against
+// escrow before that was recorded on them, as their postings show, so that
+// their postings can be made again from the transactions alone.
+func (s *Service) BackfillEscrowFlags(ctx context.Context) error {
+	return s.transactionRepo.ForEachPosted(ctx, func(tx types.Transaction) error {
+		if tx.Escrowed || (tx.Type != types.REFUND && tx.Type != types.REVERSAL) {
+			return nil
+		}
+
+		postings, err := s.postingRepo.GetForTransaction(ctx, tx.Id)
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(postings, func(p types.Posting) bool { return p.Escrow }) {
+			return nil
+		}
+		return s.transactionRepo.MarkEscrowed(ctx, tx.Id)
+	})
+}
+
+// entry returns the postings the journal books for the posted transaction tx,
+// made again from the transaction rather than read back, without applying
+// them or changing anything. Whether tx was booked against escrow, and the
+// legs it was split into, were recorded on it when it was applied.
+func (s *Service) entry(ctx context.Context, tx types.Transaction) ([]types.Posting, error) {
+	postedAt := tx.CreatedAt
+	if tx.PostedAt != nil {
+		postedAt = *tx.PostedAt
+	}
+
+	if tx.Type != types.REVERSAL {
+		return s.journal.Postings(tx, postedAt)
+	}
+
+	original, err := s.transactionRepo.GetById(ctx, tx.RelatedTransaction)
+	if err != nil {
+		return nil, err
+	}
+	reversed, err := s.entry(ctx, original)
+	if err != nil {
+		return nil, err
+	}
+	// Proceeds released before the reversal are taken from the balance.
+	if !tx.Escrowed {
+		for i := range reversed {
+			reversed[i].Escrow = false
+		}
+	}
+	return s.journal.Reversal(tx, reversed, postedAt)
+}
+
+// book adds the posting to the balance the way applying it does.
+func book(balance types.Balance, posting types.Posting) types.Balance {
+	if posting.Escrow {
+		balance.Escrow += posting.Amount
+		return balance
+	}
+	balance.Amount += posting.Amount
+	balance.TotalCommission += commission(posting)
+	return balance
+}
+
+// commission is the change the posting makes to its account's total
+// commission: the commission earned by the platform, and paid by everyone
+// else.
+func commission(posting types.Posting) types.Money {
+	if posting.Escrow || posting.Kind != types.COMMISSION_POSTING {
+		return 0
+	}
+	if posting.Platform {
+		return posting.Amount
+	}
+	return -posting.Amount
+}
@@ -0,0 +1,106 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"ledger-service/internal/core/interfaces"
+	"ledger-service/internal/core/types"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// SERVICE_LEASE is held by every running ledger service, which may run
+	// alongside each other.
+	SERVICE_LEASE = "service"
+	// MAINTENANCE_LEASE is held by commands such as rebuild-balances that
+	// need the database to themselves.
+	MAINTENANCE_LEASE = "maintenance"
+
+	leaseTTL = 30 * time.Second
+)
+
+// Lease is a lease taken with AcquireLease, renewed in the background until
+// it is released.
+type Lease struct {
+	leases interfaces.LeaseRepository
+	name   string
+	holder string
+	logger *slog.Logger
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// AcquireLease takes holder's lease on name and fails with
+// types.ErrLeaseHeld if any other process holds a lease on one of exclusive.
+// Its own lease is taken before the others are checked, so of two processes
+// starting at the same time at least one sees the other.
+func AcquireLease(ctx context.Context, leases interfaces.LeaseRepository, name, holder string, exclusive ...string) (*Lease, error) {
+	if err := leases.Renew(ctx, name, holder, leaseTTL); err != nil {
+		return nil, err
+	}
+
+	for _, other := range exclusive {
+		holders, err := leases.Holders(ctx, other)
+		if err != nil {
+			_ = leases.Release(ctx, name, holder)
+			return nil, err
+		}
+		for _, h := range holders {
+			if other == name && h == holder {
+				continue
+			}
+			_ = leases.Release(ctx, name, holder)
+			return nil, fmt.Errorf("%w: %s lease held by %s", types.ErrLeaseHeld, other, h)
+		}
+	}
+
+	lease := &Lease{
+		leases: leases,
+		name:   name,
+		holder: holder,
+		logger: slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+		stop:   make(chan struct{}),
+	}
+	lease.wg.Add(1)
+	go lease.renew()
+	return lease, nil
+}
+
+// LeaseHolder names this process as a lease holder.
+func LeaseHolder() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s/%d", host, os.Getpid())
+}
+
+func (l *Lease) renew() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), leaseTTL/3)
+			if err := l.leases.Renew(ctx, l.name, l.holder, leaseTTL); err != nil {
+				l.logger.Error("Failed to renew lease", "name", l.name, "holder", l.holder, "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Release stops renewing the lease and gives it up.
+func (l *Lease) Release(ctx context.Context) error {
+	close(l.stop)
+	l.wg.Wait()
+	return l.leases.Release(ctx, l.name, l.holder)
+}
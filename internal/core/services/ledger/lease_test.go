@@ -0,0 +1,75 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"ledger-service/internal/core/types"
+	"testing"
+	"time"
+)
+
+type fakeLeases map[string][]string
+
+func (f fakeLeases) Renew(ctx context.Context, name, holder string, ttl time.Duration) error {
+	for _, h := range f[name] {
+		if h == holder {
+			return nil
+		}
+	}
+	f[name] = append(f[name], holder)
+	return nil
+}
+
+func (f fakeLeases) Holders(ctx context.Context, name string) ([]string, error) {
+	return f[name], nil
+}
+
+func (f fakeLeases) Release(ctx context.Context, name, holder string) error {
+	for i, h := range f[name] {
+		if h == holder {
+			f[name] = append(f[name][:i], f[name][i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func TestAcquireLease(t *testing.T) {
+	ctx := context.Background()
+	leases := fakeLeases{}
+
+	first, err := AcquireLease(ctx, leases, SERVICE_LEASE, "service-1", MAINTENANCE_LEASE)
+	if err != nil {
+		t.Fatalf("first service: %v", err)
+	}
+	second, err := AcquireLease(ctx, leases, SERVICE_LEASE, "service-2", MAINTENANCE_LEASE)
+	if err != nil {
+		t.Fatalf("services should run alongside each other: %v", err)
+	}
+
+	_, err = AcquireLease(ctx, leases, MAINTENANCE_LEASE, "rebuild-1", SERVICE_LEASE, MAINTENANCE_LEASE)
+	if !errors.Is(err, types.ErrLeaseHeld) {
+		t.Fatalf("maintenance while services run: error = %v, want ErrLeaseHeld", err)
+	}
+	if holders, _ := leases.Holders(ctx, MAINTENANCE_LEASE); len(holders) != 0 {
+		t.Errorf("refused maintenance kept its lease: %v", holders)
+	}
+
+	for _, lease := range []*Lease{first, second} {
+		if err := lease.Release(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	maintenance, err := AcquireLease(ctx, leases, MAINTENANCE_LEASE, "rebuild-1", SERVICE_LEASE, MAINTENANCE_LEASE)
+	if err != nil {
+		t.Fatalf("maintenance once the services stopped: %v", err)
+	}
+	defer maintenance.Release(ctx)
+
+	if _, err := AcquireLease(ctx, leases, MAINTENANCE_LEASE, "rebuild-2", SERVICE_LEASE, MAINTENANCE_LEASE); !errors.Is(err, types.ErrLeaseHeld) {
+		t.Errorf("second maintenance: error = %v, want ErrLeaseHeld", err)
+	}
+	if _, err := AcquireLease(ctx, leases, SERVICE_LEASE, "service-1", MAINTENANCE_LEASE); !errors.Is(err, types.ErrLeaseHeld) {
+		t.Errorf("service during maintenance: error = %v, want ErrLeaseHeld", err)
+	}
+}
@@ -2,66 +2,350 @@ package ledger
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"ledger-service/internal/core/interfaces"
+	"ledger-service/internal/core/services/journal"
 	"ledger-service/internal/core/types"
 	"log/slog"
 	"os"
+	"sync/atomic"
 	"time"
 )
 
-const COMMISSION_RATE = 0.05
+const (
+	// COMMISSION_RATE applies when no default commission policy is stored.
+	COMMISSION_RATE     types.Rate = 500 // 5%
+	COMMISSION_ROUNDING            = types.RoundHalfEven
+)
+
+const (
+	// applyAttempts is how many times a balance update that fails with a
+	// transient error is tried before it is dispatched again later.
+	applyAttempts   = 5
+	applyBackoff    = 200 * time.Millisecond
+	maxApplyBackoff = 5 * time.Second
+	// maxDispatches is how many times a balance update that keeps failing is
+	// dispatched before its outbox entry is dead-lettered.
+	maxDispatches = 10
+)
+
+type Config struct {
+	// DefaultCurrency is used for transactions submitted without a currency.
+	DefaultCurrency types.Currency
+	// PlatformAccount is the account credited with every commission.
+	PlatformAccount string
+	// OutboxPollInterval is how often the relay looks for undispatched
+	// outbox entries when it was not woken up by a new transaction.
+	OutboxPollInterval time.Duration
+	// HoldTTL is how long a hold lasts before it is released automatically.
+	HoldTTL time.Duration
+	// HoldSweepInterval is how often expired holds are looked for.
+	HoldSweepInterval time.Duration
+	// EscrowClearingPeriod is how long purchase proceeds stay in the
+	// restaurant's escrow when the order is not marked delivered.
+	EscrowClearingPeriod time.Duration
+	// EscrowSweepInterval is how often proceeds due for release are looked
+	// for.
+	EscrowSweepInterval time.Duration
+	// CommissionComponents are the order components commission is charged
+	// on.
+	CommissionComponents []types.OrderComponent
+	// BalanceSnapshotInterval is how often balance snapshots are taken to
+	// speed up point-in-time balance queries.
+	BalanceSnapshotInterval time.Duration
+	// ReconciliationInterval is how often balances are reconciled with the
+	// journal.
+	ReconciliationInterval time.Duration
+	// ReconciliationRepair makes scheduled reconciliations correct the drift
+	// they find instead of only reporting it.
+	ReconciliationRepair bool
+	// Instance names this service instance. It is the holder of its
+	// SERVICE_LEASE, and the outbox entries it dispatches are recorded as
+	// its own.
+	Instance string
+}
+
+type Repositories struct {
+	Transactions       interfaces.TransactionRepository
+	Balances           interfaces.BalanceRepository
+	Outbox             interfaces.OutboxRepository
+	Idempotency        interfaces.IdempotencyRepository
+	Postings           interfaces.PostingRepository
+	CommissionPolicies interfaces.CommissionPolicyRepository
+	Snapshots          interfaces.BalanceSnapshotRepository
+	Leases             interfaces.LeaseRepository
+}
 
 type Service struct {
-	transactionRepo interfaces.TransactionRepository
-	balanceRepo     interfaces.BalanceRepository
-	queue           interfaces.Queue
-	ctx             context.Context
-	cancel          context.CancelFunc
-	logger          *slog.Logger
+	transactionRepo      interfaces.TransactionRepository
+	balanceRepo          interfaces.BalanceRepository
+	outboxRepo           interfaces.OutboxRepository
+	idempotencyRepo      interfaces.IdempotencyRepository
+	postingRepo          interfaces.PostingRepository
+	commissionPolicyRepo interfaces.CommissionPolicyRepository
+	snapshotRepo         interfaces.BalanceSnapshotRepository
+	leaseRepo            interfaces.LeaseRepository
+	journal              *journal.Journal
+	queue                interfaces.Queue
+	config               Config
+	relayWakeup          chan struct{}
+	reconciling          atomic.Bool
+	ctx                  context.Context
+	cancel               context.CancelFunc
+	logger               *slog.Logger
 }
 
-func NewService(transactionRepo interfaces.TransactionRepository, balanceRepo interfaces.BalanceRepository, queue interfaces.Queue) *Service {
+// NewService returns a service that does no background work until Start is
+// called.
+func NewService(repos Repositories, queue interfaces.Queue, config Config) *Service {
 	ctx, cancel := context.WithCancel(context.Background())
-	service := &Service{
-		transactionRepo: transactionRepo,
-		balanceRepo:     balanceRepo,
-		queue:           queue,
-		ctx:             ctx,
-		cancel:          cancel,
-		logger:          slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+	return &Service{
+		transactionRepo:      repos.Transactions,
+		balanceRepo:          repos.Balances,
+		outboxRepo:           repos.Outbox,
+		idempotencyRepo:      repos.Idempotency,
+		postingRepo:          repos.Postings,
+		commissionPolicyRepo: repos.CommissionPolicies,
+		snapshotRepo:         repos.Snapshots,
+		leaseRepo:            repos.Leases,
+		journal:              journal.New(config.PlatformAccount),
+		queue:                queue,
+		config:               config,
+		relayWakeup:          make(chan struct{}, 1),
+		ctx:                  ctx,
+		cancel:               cancel,
+		logger:               slog.New(slog.NewJSONHandler(os.Stdout, nil)),
 	}
+}
 
-	go service.processBalanceUpdates()
-
-	return service
+// Start runs the background work: the outbox relay, the balance worker, the
+// hold and escrow sweeps, balance snapshots and reconciliation. Migrations
+// that rewrite the journal must have finished before it is called.
+func (s *Service) Start() {
+	go s.relayOutbox()
+	go s.processBalanceUpdates()
+	go s.expireHolds()
+	go s.clearEscrow()
+	go s.snapshotBalances()
+	go s.reconcileBalances()
 }
 
 func (s *Service) Shutdown() {
 	s.cancel()
 }
 
-func (s *Service) SaveTransaction(ctx context.Context, transaction types.Transaction) (string, error) {
-	id, err := s.transactionRepo.Save(ctx, transaction)
+// SaveTransaction records a new transaction and schedules its balance update.
+// When idempotencyKey is set, retrying the same request with the same key
+// returns the transaction created by the first call with replayed set.
+func (s *Service) SaveTransaction(ctx context.Context, transaction types.Transaction, idempotencyKey string) (saved types.Transaction, replayed bool, err error) {
+	if transaction.Currency == "" {
+		transaction.Currency = s.config.DefaultCurrency
+	}
+
+	if idempotencyKey == "" {
+		saved, err = s.saveTransaction(ctx, transaction)
+		return saved, false, err
+	}
+
+	return s.saveTransactionIdempotent(ctx, transaction, idempotencyKey)
+}
+
+func (s *Service) saveTransaction(ctx context.Context, transaction types.Transaction) (types.Transaction, error) {
+	if transaction.Type == types.TRANSFER && transaction.Recipient != nil && transaction.Customer.Id == transaction.Recipient.Id {
+		return types.Transaction{}, types.ErrSelfTransfer
+	}
+
+	if transaction.Type == types.PURCHASE && transaction.Order != nil {
+		if err := s.splitOrder(&transaction); err != nil {
+			return types.Transaction{}, err
+		}
+	}
+
+	if err := s.validateCurrency(ctx, transaction); err != nil {
+		return types.Transaction{}, err
+	}
+
+	var saved types.Transaction
+	err := s.transactionRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.reserveFunds(ctx, &transaction); err != nil {
+			return err
+		}
+
+		var err error
+		switch transaction.Type {
+		case types.PAYOUT:
+			saved, err = s.savePayoutRequest(ctx, transaction)
+		case types.HOLD:
+			saved, err = s.saveHold(ctx, transaction)
+		default:
+			saved, err = s.saveWithOutbox(ctx, transaction)
+		}
+		return err
+	})
 	if err != nil {
-		return "", err
+		return types.Transaction{}, err
 	}
 
-	transaction.Id = id
-	s.queue.Enqueue(transaction)
+	s.wakeRelay()
 
-	return id, nil
+	return saved, nil
 }
 
-func (s *Service) GetBalance(ctx context.Context, userId string) (types.Balance, error) {
-	return s.balanceRepo.GetBalance(ctx, userId)
+// reserveFunds runs the synchronous funds check for purchases, transfers and
+// holds: the amount is reserved on the customer's balance, and released when
+// the worker applies the transaction or the hold is resolved. Refunds claim their amount from the purchase and reserve it
+// on the restaurant's balance, which may go negative. Payouts reserve their
+// amount on the restaurant's balance without overdraft. Reversals claim the
+// transaction they void, so it cannot be reversed twice.
+func (s *Service) reserveFunds(ctx context.Context, tx *types.Transaction) error {
+	switch tx.Type {
+	case types.PURCHASE, types.TRANSFER, types.HOLD:
+		if err := s.balanceRepo.Reserve(ctx, tx.Customer.Id, tx.Currency, tx.Amount); err != nil {
+			return err
+		}
+	case types.REFUND:
+		if err := s.transactionRepo.ReserveRefund(ctx, tx.RelatedTransaction, tx.Amount); err != nil {
+			return err
+		}
+		if err := s.balanceRepo.UpdateReserved(ctx, tx.Restaurant.Id, tx.Currency, tx.Amount); err != nil {
+			return err
+		}
+	case types.PAYOUT:
+		if err := s.balanceRepo.ReserveAvailable(ctx, tx.Restaurant.Id, tx.Currency, tx.Amount); err != nil {
+			return err
+		}
+	case types.REVERSAL:
+		return s.transactionRepo.ClaimReversal(ctx, tx.RelatedTransaction)
+	default:
+		return nil
+	}
+
+	tx.ReservedAmount = tx.Amount
+	return nil
 }
 
-func (s *Service) GetCustomerTransactions(ctx context.Context, customerId string) ([]types.Transaction, error) {
-	return s.transactionRepo.GetManyForCustomer(ctx, customerId)
+// reservedAccount returns the account whose funds were reserved for tx.
+func reservedAccount(tx types.Transaction) string {
+	if tx.Type == types.REFUND || tx.Type == types.PAYOUT {
+		return tx.Restaurant.Id
+	}
+	return tx.Customer.Id
+}
+
+func (s *Service) SetOverdraftLimit(ctx context.Context, userId string, currency types.Currency, limit types.Money) (types.Balance, error) {
+	if !currency.Valid() {
+		return types.Balance{}, fmt.Errorf("%w: %s", types.ErrUnsupportedCurrency, currency)
+	}
+	return s.balanceRepo.SetOverdraftLimit(ctx, userId, currency, limit)
+}
+
+// GetBalances returns the user's balance in every currency they hold, or only
+// in the given currency when one is set.
+func (s *Service) GetBalances(ctx context.Context, userId string, currency types.Currency) ([]types.Balance, error) {
+	if currency == "" {
+		return s.balanceRepo.GetBalances(ctx, userId)
+	}
+
+	if !currency.Valid() {
+		return nil, fmt.Errorf("%w: %s", types.ErrUnsupportedCurrency, currency)
+	}
+
+	balance, err := s.balanceRepo.GetBalance(ctx, userId, currency)
+	if err != nil {
+		return nil, err
+	}
+	return []types.Balance{balance}, nil
 }
 
-func (s *Service) GetRestaurantTransactions(ctx context.Context, restaurantId string) ([]types.Transaction, error) {
-	return s.transactionRepo.GetManyForRestaurant(ctx, restaurantId)
+// GetCustomerTransactions returns a page of the customer's transactions
+// matching filter, newest first. The page starts after cursor if it is set;
+// the returned cursor is empty on the last page.
+func (s *Service) GetCustomerTransactions(ctx context.Context, customerId string, filter types.TransactionFilter, cursor string, limit int) ([]types.Transaction, string, error) {
+	return listTransactions(ctx, s.transactionRepo.GetManyForCustomer, customerId, filter, cursor, limit)
+}
+
+func (s *Service) GetCourierTransactions(ctx context.Context, courierId string, filter types.TransactionFilter, cursor string, limit int) ([]types.Transaction, string, error) {
+	return listTransactions(ctx, s.transactionRepo.GetManyForCourier, courierId, filter, cursor, limit)
+}
+
+func (s *Service) GetRestaurantTransactions(ctx context.Context, restaurantId string, filter types.TransactionFilter, cursor string, limit int) ([]types.Transaction, string, error) {
+	return listTransactions(ctx, s.transactionRepo.GetManyForRestaurant, restaurantId, filter, cursor, limit)
+}
+
+// ExportCustomerTransactions calls fn for each of the customer's transactions
+// matching filter, oldest first, as they are read from the database.
+func (s *Service) ExportCustomerTransactions(ctx context.Context, customerId string, filter types.TransactionFilter, fn func(types.Transaction) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	return s.transactionRepo.EachForCustomer(ctx, customerId, filter, fn)
+}
+
+// ExportRestaurantTransactions calls fn for each of the restaurant's
+// transactions matching filter, oldest first, as they are read from the
+// database.
+func (s *Service) ExportRestaurantTransactions(ctx context.Context, restaurantId string, filter types.TransactionFilter, fn func(types.Transaction) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	return s.transactionRepo.EachForRestaurant(ctx, restaurantId, filter, fn)
+}
+
+type listFunc func(ctx context.Context, id string, filter types.TransactionFilter, after *types.Cursor, limit int) ([]types.Transaction, error)
+
+func listTransactions(ctx context.Context, list listFunc, id string, filter types.TransactionFilter, cursor string, limit int) ([]types.Transaction, string, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	var after *types.Cursor
+	if cursor != "" {
+		parsed, err := types.ParseCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &parsed
+	}
+
+	// One more than asked tells whether there is a next page.
+	transactions, err := list(ctx, id, filter, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(transactions) <= limit {
+		return transactions, "", nil
+	}
+
+	transactions = transactions[:limit]
+	last := transactions[limit-1]
+	return transactions, types.Cursor{At: last.CreatedAt, Id: last.Id}.Encode(), nil
+}
+
+// validateCurrency checks that the transaction's currency is supported and,
+// for purchases and transfers, that the paying customer holds a wallet in
+// that currency.
+func (s *Service) validateCurrency(ctx context.Context, tx types.Transaction) error {
+	if !tx.Currency.Valid() {
+		return fmt.Errorf("%w: %s", types.ErrUnsupportedCurrency, tx.Currency)
+	}
+
+	if tx.Type != types.PURCHASE && tx.Type != types.TRANSFER && tx.Type != types.HOLD {
+		return nil
+	}
+
+	wallets, err := s.balanceRepo.GetBalances(ctx, tx.Customer.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, wallet := range wallets {
+		if wallet.Currency == tx.Currency {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: customer %s has no %s wallet", types.ErrCurrencyMismatch, tx.Customer.Id, tx.Currency)
 }
 
 func (s *Service) processBalanceUpdates() {
@@ -75,80 +359,355 @@ func (s *Service) processBalanceUpdates() {
 				continue
 			}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-
-			if err := s.updateBalances(ctx, tx); err != nil {
+			err := s.applyWithRetry(tx)
+			switch {
+			case err == nil:
+			case s.ctx.Err() != nil:
+				// Shutting down; the entry is dispatched again on restart.
+			case !permanent(err) || !failable(tx):
+				s.redispatch(tx, err)
+			default:
 				s.logger.Error("Balance update failed",
 					"error", err.Error(),
 					"transaction_id", tx.Id,
 					"transaction_type", string(tx.Type),
 					"amount", tx.Amount,
+					"currency", string(tx.Currency),
 				)
-				cancel()
-				continue
+
+				// The apply context may have expired, which is what failed it.
+				failCtx, failCancel := context.WithTimeout(context.Background(), 10*time.Second)
+				if err := s.failTransaction(failCtx, tx, err.Error()); err != nil {
+					s.logger.Error("Marking transaction failed did not succeed",
+						"error", err.Error(),
+						"transaction_id", tx.Id,
+					)
+				}
+				failCancel()
 			}
+		}
+	}
+}
+
+// applyWithRetry applies the transaction, retrying with exponential backoff
+// while it fails for reasons that may go away, such as a dropped connection,
+// a timeout or a write conflict. It gives up after applyAttempts, or as soon
+// as the failure is permanent.
+func (s *Service) applyWithRetry(tx types.Transaction) error {
+	backoff := applyBackoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := s.applyTransaction(ctx, tx)
+		cancel()
+
+		if err == nil || permanent(err) || attempt == applyAttempts {
+			return err
+		}
+
+		s.logger.Warn("Balance update failed, retrying",
+			"error", err.Error(),
+			"transaction_id", tx.Id,
+			"attempt", attempt,
+			"backoff", backoff.String(),
+		)
+
+		select {
+		case <-s.ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxApplyBackoff)
+	}
+}
 
-			s.processCommission(ctx, tx)
+// permanentErrors are the failures that applying the transaction again would
+// run into the same way: the transaction is invalid or the journal rejects it.
+var permanentErrors = []error{
+	types.ErrUnbalancedJournal,
+	types.ErrNoPostings,
+	types.ErrNotReversible,
+	types.ErrAlreadyReversed,
+	types.ErrAmountOverflow,
+	types.ErrDivisionByZero,
+	types.ErrInvalidOrder,
+	types.ErrUnsupportedCurrency,
+	types.ErrTransactionNotFound,
+	types.ErrRefundExceedsPurchase,
+	types.ErrNotRefundable,
+	types.ErrEscrowNotHeld,
+}
 
-			cancel()
+// permanent reports whether err is one of permanentErrors. Anything else is
+// assumed to be transient.
+func permanent(err error) bool {
+	for _, target := range permanentErrors {
+		if errors.Is(err, target) {
+			return true
 		}
 	}
+	return false
 }
 
-func (s *Service) updateBalances(ctx context.Context, transaction types.Transaction) error {
-	switch transaction.Type {
-	case types.DEPOSIT:
-		return s.balanceRepo.UpdateBalance(ctx, transaction.Customer.Id, transaction.Amount)
-	case types.PURCHASE:
-		if err := s.balanceRepo.UpdateBalance(ctx, transaction.Customer.Id, -transaction.Amount); err != nil {
+// failable reports whether tx may be marked FAILED when applying it fails. A
+// settled payout was paid out already and an escrow release moves proceeds
+// the restaurant is owed, so those are only ever retried.
+func failable(tx types.Transaction) bool {
+	return tx.Type != types.PAYOUT && tx.Type != types.ESCROW_RELEASE
+}
+
+// redispatch hands a transaction that kept failing with cause back to the
+// outbox, so the relay dispatches it again on its next poll. After
+// maxDispatches, or at once if it can never succeed, its outbox entry is
+// dead-lettered instead and the transaction stays PENDING until an operator
+// looks into it.
+func (s *Service) redispatch(tx types.Transaction, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	failures, err := s.outboxRepo.RecordFailure(ctx, tx.Id, cause.Error())
+	if err != nil {
+		s.logger.Error("Recording failed balance update did not succeed",
+			"error", err.Error(),
+			"transaction_id", tx.Id,
+		)
+	}
+
+	if failures < maxDispatches && !permanent(cause) {
+		s.logger.Error("Balance update keeps failing, dispatching it again later",
+			"error", cause.Error(),
+			"transaction_id", tx.Id,
+			"transaction_type", string(tx.Type),
+			"failures", failures,
+		)
+		if err := s.outboxRepo.Redispatch(ctx, tx.Id); err != nil {
+			s.logger.Error("Dispatching transaction again did not succeed",
+				"error", err.Error(),
+				"transaction_id", tx.Id,
+			)
+		}
+		return
+	}
+
+	s.logger.Error("Balance update dead-lettered, it needs manual attention",
+		"error", cause.Error(),
+		"transaction_id", tx.Id,
+		"transaction_type", string(tx.Type),
+		"failures", failures,
+	)
+	if err := s.outboxRepo.DeadLetter(ctx, tx.Id); err != nil {
+		s.logger.Error("Dead-lettering transaction did not succeed",
+			"error", err.Error(),
+			"transaction_id", tx.Id,
+		)
+	}
+}
+
+// applyTransaction applies every balance effect of the transaction, including
+// its commission, and marks it POSTED in a single database transaction.
+// Transactions that are not PENDING anymore are skipped.
+func (s *Service) applyTransaction(ctx context.Context, tx types.Transaction) error {
+	return s.balanceRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		postedAt := time.Now()
+		posted, err := s.transactionRepo.MarkPosted(ctx, tx.Id, postedAt)
+		if err != nil {
 			return err
 		}
-		return s.balanceRepo.UpdateBalance(ctx, transaction.Restaurant.Id, transaction.Amount)
-	case types.COMMISSION:
-		// Deduct from current balance
-		if err := s.balanceRepo.UpdateBalance(ctx, transaction.Restaurant.Id, -transaction.Amount); err != nil {
+
+		if posted {
+			if err := s.splitRefund(ctx, &tx); err != nil {
+				return err
+			}
+			if err := s.bookEscrow(ctx, &tx, postedAt); err != nil {
+				return err
+			}
+			if err := s.updateBalances(ctx, tx, postedAt); err != nil {
+				return err
+			}
+			if err := s.processCommission(ctx, tx, postedAt); err != nil {
+				return err
+			}
+		}
+
+		return s.outboxRepo.MarkApplied(ctx, tx.Id)
+	})
+}
+
+// failTransaction marks a transaction whose balance update failed as FAILED
+// and gives back any funds reserved for it.
+func (s *Service) failTransaction(ctx context.Context, tx types.Transaction, reason string) error {
+	if !failable(tx) {
+		return fmt.Errorf("a %s is never marked failed", tx.Type)
+	}
+
+	return s.balanceRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		failed, err := s.transactionRepo.MarkFailed(ctx, tx.Id, reason)
+		if err != nil {
 			return err
 		}
-		// Track cumulative commission earned
-		return s.balanceRepo.UpdateTotalCommission(ctx, transaction.Restaurant.Id, transaction.Amount)
+
+		if failed && tx.ReservedAmount > 0 {
+			if err := s.balanceRepo.UpdateReserved(ctx, reservedAccount(tx), tx.Currency, -tx.ReservedAmount); err != nil {
+				return err
+			}
+		}
+
+		if failed && tx.Type == types.REFUND {
+			if err := s.transactionRepo.ReleaseRefund(ctx, tx.RelatedTransaction, tx.Amount); err != nil {
+				return err
+			}
+		}
+
+		if failed && tx.Type == types.REVERSAL {
+			if err := s.transactionRepo.ReleaseReversal(ctx, tx.RelatedTransaction); err != nil {
+				return err
+			}
+		}
+
+		return s.outboxRepo.MarkApplied(ctx, tx.Id)
+	})
+}
+
+func (s *Service) GetTransaction(ctx context.Context, id string) (types.Transaction, error) {
+	return s.transactionRepo.GetById(ctx, id)
+}
+
+// updateBalances applies the transaction's postings to the balances of the
+// accounts involved and records them in the journal.
+func (s *Service) updateBalances(ctx context.Context, transaction types.Transaction, postedAt time.Time) error {
+	postings, err := s.postings(ctx, transaction, postedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range postings {
+		if err := s.applyPosting(ctx, &postings[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := s.postingRepo.SaveMany(ctx, postings); err != nil {
+		return err
+	}
+
+	// The transaction was charged from funds reserved when it was accepted.
+	if transaction.ReservedAmount > 0 {
+		return s.balanceRepo.UpdateReserved(ctx, reservedAccount(transaction), transaction.Currency, -transaction.ReservedAmount)
 	}
 	return nil
 }
 
-func (s *Service) processCommission(ctx context.Context, tx types.Transaction) {
-	if !s.shouldApplyCommission(tx) {
-		return
+func (s *Service) postings(ctx context.Context, transaction types.Transaction, postedAt time.Time) ([]types.Posting, error) {
+	if transaction.Type == types.REVERSAL {
+		return s.reversalPostings(ctx, transaction, postedAt)
 	}
+	return s.journal.Postings(transaction, postedAt)
+}
+
+// applyPosting applies the posting to its account's balance, or escrow, and
+// records on it the balance that results and its place in the account's
+// history.
+func (s *Service) applyPosting(ctx context.Context, posting *types.Posting) error {
+	return s.project(ctx, s.balanceRepo, posting)
+}
 
-	commissionTx := s.buildCommissionTransaction(tx)
+// project applies the posting to the balances kept by balances. Rebuilding
+// the balances replays the journal through it into a fresh collection.
+func (s *Service) project(ctx context.Context, balances interfaces.BalanceRepository, posting *types.Posting) error {
+	update := balances.UpdateBalance
+	if posting.Escrow {
+		update = balances.UpdateEscrow
+	}
+
+	balance, err := update(ctx, posting.Account, posting.Currency, posting.Amount)
+	if err != nil {
+		return err
+	}
+
+	posting.BalanceAfter = &balance.Amount
+	posting.Sequence = balance.Sequence
+	if posting.Escrow || balance.Escrow != 0 {
+		posting.EscrowAfter = &balance.Escrow
+	}
+
+	// Track cumulative commission earned by the platform and paid by each
+	// restaurant
+	if commission := commission(*posting); commission != 0 {
+		return balances.UpdateTotalCommission(ctx, posting.Account, posting.Currency, commission)
+	}
+	return nil
+}
+
+func (s *Service) GetPostings(ctx context.Context, transactionId string) ([]types.Posting, error) {
+	if _, err := s.transactionRepo.GetById(ctx, transactionId); err != nil {
+		return nil, err
+	}
+	return s.postingRepo.GetForTransaction(ctx, transactionId)
+}
+
+// processCommission records the commission owed for tx, returned by it or
+// voided with it, and applies it right away; it is expected to run inside the
+// transaction that applies tx.
+func (s *Service) processCommission(ctx context.Context, tx types.Transaction, postedAt time.Time) error {
+	var commissionTx types.Transaction
+	var err error
+
+	switch tx.Type {
+	case types.PURCHASE:
+		commissionTx, err = s.buildCommissionTransaction(ctx, tx)
+	case types.REFUND:
+		commissionTx, err = s.buildCommissionRefund(ctx, tx)
+	case types.REVERSAL:
+		return s.reverseCommission(ctx, tx, postedAt)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("build %s commission: %w", tx.Type, err)
+	}
 	if commissionTx.Amount <= 0 {
-		return
+		return nil
 	}
 
+	commissionTx.Status = types.POSTED
+	commissionTx.PostedAt = &postedAt
+
 	id, err := s.transactionRepo.Save(ctx, commissionTx)
 	if err != nil {
-		s.logger.Error("Commission transaction save failed",
-			"error", err.Error(),
-			"original_transaction_id", tx.Id,
-			"commission_amount", commissionTx.Amount,
-		)
-		return
+		return fmt.Errorf("save commission transaction: %w", err)
 	}
-
 	commissionTx.Id = id
-	s.queue.Enqueue(commissionTx)
-}
 
-func (s *Service) shouldApplyCommission(tx types.Transaction) bool {
-	return tx.Type == types.PURCHASE
+	return s.updateBalances(ctx, commissionTx, postedAt)
 }
 
-func (s *Service) buildCommissionTransaction(tx types.Transaction) types.Transaction {
+func (s *Service) buildCommissionTransaction(ctx context.Context, tx types.Transaction) (types.Transaction, error) {
+	amount, rate, err := s.commission(ctx, tx)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
 	return types.Transaction{
 		Type:               types.COMMISSION,
-		Amount:             tx.Amount * COMMISSION_RATE,
+		Amount:             amount,
+		Currency:           tx.Currency,
 		Restaurant:         tx.Restaurant,
+		Platform:           s.platform(),
 		RelatedTransaction: tx.Id,
+		CommissionRate:     rate,
 		CreatedAt:          time.Now(),
-	}
+	}, nil
+}
+
+func (s *Service) platform() *types.User {
+	return &types.User{Id: s.journal.PlatformAccount(), Type: types.PLATFORM}
+}
+
+func (s *Service) PlatformAccount() string {
+	return s.journal.PlatformAccount()
+}
+
+// GetPlatformRevenue totals the commission credited to the platform account
+// per currency for postings booked in [from, to).
+func (s *Service) GetPlatformRevenue(ctx context.Context, from, to time.Time) ([]types.AccountTotal, error) {
+	return s.postingRepo.SumByCurrency(ctx, s.journal.PlatformAccount(), types.COMMISSION_POSTING, from, to)
 }
@@ -0,0 +1,64 @@
+package ledger
+
+import (
+	"fmt"
+	"ledger-service/internal/core/types"
+	"slices"
+)
+
+// splitOrder prices a purchase placed with an order breakdown and records the
+// leg credited to each party: the items go to the restaurant, the service fee
+// to the platform, and the delivery fee and tip to the courier, or to the
+// restaurant when it delivers the order itself. Commission is charged on the
+// restaurant's legs for the components in Config.CommissionComponents.
+func (s *Service) splitOrder(tx *types.Transaction) error {
+	if err := tx.Order.Validate(); err != nil {
+		return err
+	}
+
+	total, err := tx.Order.Total()
+	if err != nil {
+		return err
+	}
+	if tx.Amount != 0 && tx.Amount != total {
+		return fmt.Errorf("%w: amount %d does not match the order total %d", types.ErrInvalidOrder, tx.Amount, total)
+	}
+	tx.Amount = total
+
+	deliverer := tx.Restaurant
+	if tx.Courier != nil && tx.Courier.Id != "" {
+		deliverer = *tx.Courier
+	}
+
+	components, err := tx.Order.Components()
+	if err != nil {
+		return err
+	}
+
+	tx.Legs = []types.Leg{}
+	for _, component := range components {
+		if component.Amount == 0 {
+			continue
+		}
+
+		var payee types.User
+		switch component.Component {
+		case types.ITEMS:
+			payee = tx.Restaurant
+		case types.SERVICE_FEE:
+			tx.Platform = s.platform()
+			payee = *tx.Platform
+		default:
+			payee = deliverer
+		}
+
+		tx.Legs = append(tx.Legs, types.Leg{
+			Component:      component.Component,
+			Payee:          payee,
+			Amount:         component.Amount,
+			Commissionable: payee.Id == tx.Restaurant.Id && slices.Contains(s.config.CommissionComponents, component.Component),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package ledger
+
+import (
+	"errors"
+	"ledger-service/internal/core/services/journal"
+	"ledger-service/internal/core/types"
+	"math"
+	"testing"
+)
+
+func TestSplitOrder(t *testing.T) {
+	s := &Service{
+		config:  Config{CommissionComponents: []types.OrderComponent{types.ITEMS, types.DELIVERY_FEE}},
+		journal: journal.New("platform:revenue"),
+	}
+	restaurant := types.User{Id: "restaurant-1", Type: types.RESTAURANT}
+	courier := types.User{Id: "courier-1", Type: types.COURIER}
+	order := types.Order{
+		Items:       []types.LineItem{{Name: "Pizza", Quantity: 2, UnitPrice: 1000}},
+		DeliveryFee: 300,
+		ServiceFee:  150,
+		Tip:         200,
+	}
+
+	tests := []struct {
+		name    string
+		courier *types.User
+		want    []types.Leg
+	}{
+		{"delivered by a courier", &courier, []types.Leg{
+			{Component: types.ITEMS, Payee: restaurant, Amount: 2000, Commissionable: true},
+			{Component: types.DELIVERY_FEE, Payee: courier, Amount: 300},
+			{Component: types.SERVICE_FEE, Payee: types.User{Id: "platform:revenue", Type: types.PLATFORM}, Amount: 150},
+			{Component: types.TIP, Payee: courier, Amount: 200},
+		}},
+		{"delivered by the restaurant", nil, []types.Leg{
+			{Component: types.ITEMS, Payee: restaurant, Amount: 2000, Commissionable: true},
+			{Component: types.DELIVERY_FEE, Payee: restaurant, Amount: 300, Commissionable: true},
+			{Component: types.SERVICE_FEE, Payee: types.User{Id: "platform:revenue", Type: types.PLATFORM}, Amount: 150},
+			{Component: types.TIP, Payee: restaurant, Amount: 200},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := order
+			tx := types.Transaction{Type: types.PURCHASE, Restaurant: restaurant, Courier: tt.courier, Order: &o}
+			if err := s.splitOrder(&tx); err != nil {
+				t.Fatalf("splitOrder returned error: %v", err)
+			}
+
+			if tx.Amount != 2650 {
+				t.Errorf("amount = %d, want the order total 2650", tx.Amount)
+			}
+			if tx.Platform == nil || tx.Platform.Id != "platform:revenue" {
+				t.Errorf("platform = %v, want platform:revenue", tx.Platform)
+			}
+			if len(tx.Legs) != len(tt.want) {
+				t.Fatalf("got %d legs, want %d: %+v", len(tx.Legs), len(tt.want), tx.Legs)
+			}
+			for i, leg := range tx.Legs {
+				if leg != tt.want[i] {
+					t.Errorf("leg %d = %+v, want %+v", i, leg, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSplitOrderRejects(t *testing.T) {
+	s := &Service{journal: journal.New("platform:revenue")}
+	items := []types.LineItem{{Name: "Pizza", Quantity: 1, UnitPrice: 1000}}
+
+	tests := []struct {
+		name   string
+		amount types.Money
+		order  types.Order
+	}{
+		{"amount other than the total", 900, types.Order{Items: items}},
+		{"empty order", 0, types.Order{}},
+		{"negative fee", 0, types.Order{Items: items, DeliveryFee: -1}},
+		// Wraps around to an amount of 1 without overflow checks.
+		{"fees overflowing the total", 0, types.Order{DeliveryFee: math.MaxInt64, ServiceFee: math.MaxInt64, Tip: 3}},
+		{"line item overflowing its total", 0, types.Order{Items: []types.LineItem{{Name: "Pizza", Quantity: 2, UnitPrice: math.MaxInt64/2 + 1}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := types.Transaction{Type: types.PURCHASE, Amount: tt.amount, Order: &tt.order}
+			if err := s.splitOrder(&tx); !errors.Is(err, types.ErrInvalidOrder) {
+				t.Errorf("splitOrder error = %v, want ErrInvalidOrder", err)
+			}
+		})
+	}
+}
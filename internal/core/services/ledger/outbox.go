@@ -0,0 +1,122 @@
+package ledger
+
+import (
+	"context"
+	"ledger-service/internal/core/types"
+	"slices"
+	"time"
+)
+
+// saveWithOutbox persists the transaction together with the outbox entry that
+// schedules its balance update, so neither can exist without the other.
+func (s *Service) saveWithOutbox(ctx context.Context, transaction types.Transaction) (types.Transaction, error) {
+	transaction.Status = types.PENDING
+
+	err := s.transactionRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		id, err := s.transactionRepo.Save(ctx, transaction)
+		if err != nil {
+			return err
+		}
+		transaction.Id = id
+
+		return s.outboxRepo.Add(ctx, types.OutboxEntry{
+			TransactionId: id,
+			Transaction:   transaction,
+			CreatedAt:     time.Now(),
+		})
+	})
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
+// wakeRelay asks the relay to dispatch new outbox entries without waiting for
+// the next poll.
+func (s *Service) wakeRelay() {
+	select {
+	case s.relayWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// relayOutbox drains undispatched outbox entries into the queue. On startup it
+// first re-dispatches every entry that was not applied, since anything that
+// sat in the in-memory queue of a previous process was lost with it. Entries
+// dispatched to other instances that are still running, and so still hold
+// their SERVICE_LEASE, are left to them; those of instances whose lease
+// lapsed are taken back every leaseTTL.
+func (s *Service) relayOutbox() {
+	ticker := time.NewTicker(s.config.OutboxPollInterval)
+	defer ticker.Stop()
+	recovery := time.NewTicker(leaseTTL)
+	defer recovery.Stop()
+
+	recovered := false
+	for {
+		if !recovered {
+			recovered = s.recoverOutbox(true)
+		}
+		if recovered {
+			s.dispatchOutbox()
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.relayWakeup:
+		case <-recovery.C:
+			if recovered {
+				s.recoverOutbox(false)
+			}
+		}
+	}
+}
+
+// recoverOutbox makes the entries dispatched to instances that are not
+// running anymore eligible for dispatch again. At startup that includes the
+// entries recorded as this instance's, which were dispatched to an earlier
+// process of the same name.
+func (s *Service) recoverOutbox(startup bool) bool {
+	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+	defer cancel()
+
+	holders, err := s.leaseRepo.Holders(ctx, SERVICE_LEASE)
+	if err != nil {
+		s.logger.Error("Outbox recovery failed", "error", err.Error())
+		return false
+	}
+	running := holders
+	if startup {
+		running = slices.DeleteFunc(holders, func(holder string) bool { return holder == s.config.Instance })
+	} else if !slices.Contains(running, s.config.Instance) {
+		// This instance's own lease lapsed; its entries are still its own.
+		running = append(running, s.config.Instance)
+	}
+
+	if err := s.outboxRepo.ResetDispatched(ctx, running); err != nil {
+		s.logger.Error("Outbox recovery failed", "error", err.Error())
+		return false
+	}
+	return true
+}
+
+func (s *Service) dispatchOutbox() {
+	for {
+		ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+		entry, ok, err := s.outboxRepo.ClaimNext(ctx, s.config.Instance)
+		cancel()
+
+		if err != nil {
+			s.logger.Error("Outbox dispatch failed", "error", err.Error())
+			return
+		}
+		if !ok {
+			return
+		}
+
+		s.queue.Enqueue(entry.Transaction)
+	}
+}
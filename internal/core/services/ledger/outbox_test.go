@@ -0,0 +1,48 @@
+package ledger
+
+import (
+	"context"
+	"ledger-service/internal/core/interfaces"
+	"slices"
+	"testing"
+)
+
+// fakeOutbox records the instances whose entries ResetDispatched keeps.
+type fakeOutbox struct {
+	interfaces.OutboxRepository
+	kept []string
+}
+
+func (f *fakeOutbox) ResetDispatched(ctx context.Context, keep []string) error {
+	f.kept = slices.Clone(keep)
+	return nil
+}
+
+func TestRecoverOutbox(t *testing.T) {
+	tests := []struct {
+		name    string
+		holders []string
+		startup bool
+		want    []string
+	}{
+		{"startup takes back the entries of an earlier process of the same name", []string{"host/1", "host/2"}, true, []string{"host/2"}},
+		{"later keeps its own entries", []string{"host/1", "host/2"}, false, []string{"host/1", "host/2"}},
+		{"later keeps its own entries after its lease lapsed", []string{"host/2"}, false, []string{"host/2", "host/1"}},
+		{"later takes back the entries of instances that stopped", []string{"host/1"}, false, []string{"host/1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leases := fakeLeases{SERVICE_LEASE: slices.Clone(tt.holders)}
+			outbox := &fakeOutbox{}
+			s := NewService(Repositories{Outbox: outbox, Leases: leases}, nil, Config{Instance: "host/1"})
+
+			if !s.recoverOutbox(tt.startup) {
+				t.Fatal("recoverOutbox failed")
+			}
+			if !slices.Equal(outbox.kept, tt.want) {
+				t.Errorf("kept the entries of %v, want %v", outbox.kept, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"time"
+)
+
+// RequestPayout starts paying amount out of the restaurant's balance. The
+// amount is reserved right away, so it only succeeds if the balance not yet
+// reserved for pending refunds and other payouts covers it; the restaurant's
+// overdraft limit does not count. The payout must then be approved and
+// settled before it is applied.
+func (s *Service) RequestPayout(ctx context.Context, restaurantId string, amount types.Money, currency types.Currency, idempotencyKey string) (types.Transaction, bool, error) {
+	payout := types.Transaction{
+		Type:     types.PAYOUT,
+		Amount:   amount,
+		Currency: currency,
+		Restaurant: types.User{
+			Id:   restaurantId,
+			Type: types.RESTAURANT,
+		},
+		CreatedAt: time.Now(),
+	}
+
+	return s.SaveTransaction(ctx, payout, idempotencyKey)
+}
+
+// savePayoutRequest stores a requested payout. Unlike other transactions it
+// gets no outbox entry until it is settled.
+func (s *Service) savePayoutRequest(ctx context.Context, payout types.Transaction) (types.Transaction, error) {
+	payout.Status = types.PENDING
+	payout.PayoutStatus = types.PAYOUT_REQUESTED
+
+	id, err := s.transactionRepo.Save(ctx, payout)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	payout.Id = id
+
+	return payout, nil
+}
+
+func (s *Service) GetPayouts(ctx context.Context, restaurantId string) ([]types.Transaction, error) {
+	filter := types.TransactionFilter{Types: []types.TransactionType{types.PAYOUT}}
+	return s.transactionRepo.GetManyForRestaurant(ctx, restaurantId, filter, nil, 0)
+}
+
+func (s *Service) GetPayout(ctx context.Context, restaurantId, payoutId string) (types.Transaction, error) {
+	payout, err := s.transactionRepo.GetById(ctx, payoutId)
+	if err != nil && !errors.Is(err, types.ErrTransactionNotFound) {
+		return types.Transaction{}, err
+	}
+
+	if err != nil || payout.Type != types.PAYOUT || payout.Restaurant.Id != restaurantId {
+		return types.Transaction{}, fmt.Errorf("%w: %s", types.ErrPayoutNotFound, payoutId)
+	}
+	return payout, nil
+}
+
+func (s *Service) ApprovePayout(ctx context.Context, restaurantId, payoutId string) (types.Transaction, error) {
+	return s.transitionPayout(ctx, restaurantId, payoutId, []types.PayoutStatus{types.PAYOUT_REQUESTED}, types.PAYOUT_APPROVED, nil)
+}
+
+// SettlePayout records that an approved payout was paid and schedules it to
+// be applied: the reserved amount then leaves the restaurant's balance.
+func (s *Service) SettlePayout(ctx context.Context, restaurantId, payoutId string) (types.Transaction, error) {
+	payout, err := s.transitionPayout(ctx, restaurantId, payoutId, []types.PayoutStatus{types.PAYOUT_APPROVED}, types.PAYOUT_SETTLED,
+		func(ctx context.Context, payout types.Transaction) error {
+			return s.outboxRepo.Add(ctx, types.OutboxEntry{
+				TransactionId: payout.Id,
+				Transaction:   payout,
+				CreatedAt:     time.Now(),
+			})
+		})
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	s.wakeRelay()
+
+	return payout, nil
+}
+
+// RejectPayout cancels a payout that was not settled yet and releases its
+// reserved amount.
+func (s *Service) RejectPayout(ctx context.Context, restaurantId, payoutId, reason string) (types.Transaction, error) {
+	from := []types.PayoutStatus{types.PAYOUT_REQUESTED, types.PAYOUT_APPROVED}
+
+	return s.transitionPayout(ctx, restaurantId, payoutId, from, types.PAYOUT_REJECTED,
+		func(ctx context.Context, payout types.Transaction) error {
+			if _, err := s.transactionRepo.MarkFailed(ctx, payout.Id, reason); err != nil {
+				return err
+			}
+			return s.balanceRepo.UpdateReserved(ctx, payout.Restaurant.Id, payout.Currency, -payout.ReservedAmount)
+		})
+}
+
+// transitionPayout moves the payout to the to status and runs then, if set,
+// in the same database transaction. It returns the updated payout.
+func (s *Service) transitionPayout(ctx context.Context, restaurantId, payoutId string, from []types.PayoutStatus, to types.PayoutStatus, then func(ctx context.Context, payout types.Transaction) error) (types.Transaction, error) {
+	var payout types.Transaction
+	err := s.transactionRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		payout, err = s.GetPayout(ctx, restaurantId, payoutId)
+		if err != nil {
+			return err
+		}
+
+		moved, err := s.transactionRepo.TransitionPayout(ctx, payoutId, from, to)
+		if err != nil {
+			return err
+		}
+		if !moved {
+			return fmt.Errorf("%w: payout %s is %s", types.ErrPayoutStatusConflict, payoutId, payout.PayoutStatus)
+		}
+		payout.PayoutStatus = to
+
+		if then != nil {
+			if err := then(ctx, payout); err != nil {
+				return err
+			}
+		}
+
+		payout, err = s.transactionRepo.GetById(ctx, payoutId)
+		return err
+	})
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	return payout, nil
+}
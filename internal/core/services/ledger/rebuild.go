@@ -0,0 +1,104 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"ledger-service/internal/core/interfaces"
+	"ledger-service/internal/core/types"
+	"time"
+)
+
+// RebuildBalances books every posted transaction again, oldest first, with
+// the current journal: its postings are made again from the transaction, as
+// reconciliation does, applied to shadowBalances through the same code that
+// applies them when transactions are posted, and saved to shadowPostings.
+// Both must be empty. Holds book no postings and are skipped. Reservations
+// and overdraft limits do not come from posted transactions and are carried
+// over from the live balances, as are wallets that never had postings. The
+// live balances and postings are left untouched; the report lists the
+// wallets that the rebuild changes.
+//
+// Nothing else may write balances or postings while it runs; callers hold
+// the MAINTENANCE_LEASE, which the service cannot run alongside.
+func RebuildBalances(ctx context.Context, repos Repositories, config Config, shadowBalances interfaces.BalanceRepository, shadowPostings interfaces.PostingRepository) (types.RebuildReport, error) {
+	return NewService(repos, nil, config).rebuildBalances(ctx, shadowBalances, shadowPostings)
+}
+
+func (s *Service) rebuildBalances(ctx context.Context, shadow interfaces.BalanceRepository, shadowPostings interfaces.PostingRepository) (types.RebuildReport, error) {
+	report := types.RebuildReport{
+		StartedAt: time.Now(),
+		Changed:   []types.BalanceDrift{},
+	}
+
+	err := s.transactionRepo.ForEachPosted(ctx, func(tx types.Transaction) error {
+		postings, err := s.entry(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("transaction %s: %w", tx.Id, err)
+		}
+
+		for i := range postings {
+			if err := s.project(ctx, shadow, &postings[i]); err != nil {
+				return err
+			}
+		}
+		if err := shadowPostings.SaveMany(ctx, postings); err != nil {
+			return err
+		}
+
+		report.Transactions++
+		report.Postings += len(postings)
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	err = s.balanceRepo.ForEach(ctx, func(live types.Balance) error {
+		// Upserts wallets that never had postings.
+		if _, err := shadow.UpdateBalance(ctx, live.UserId, live.Currency, 0); err != nil {
+			return err
+		}
+		if live.Reserved != 0 {
+			if err := shadow.UpdateReserved(ctx, live.UserId, live.Currency, live.Reserved); err != nil {
+				return err
+			}
+		}
+		if live.OverdraftLimit != 0 {
+			if _, err := shadow.SetOverdraftLimit(ctx, live.UserId, live.Currency, live.OverdraftLimit); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	err = shadow.ForEach(ctx, func(rebuilt types.Balance) error {
+		report.Wallets++
+
+		live, err := s.balanceRepo.GetBalance(ctx, rebuilt.UserId, rebuilt.Currency)
+		if err != nil {
+			return err
+		}
+		if drifted(live, rebuilt) {
+			report.Changed = append(report.Changed, types.BalanceDrift{
+				Account:            rebuilt.UserId,
+				Currency:           rebuilt.Currency,
+				StoredAmount:       live.Amount,
+				ExpectedAmount:     rebuilt.Amount,
+				StoredEscrow:       live.Escrow,
+				ExpectedEscrow:     rebuilt.Escrow,
+				StoredCommission:   live.TotalCommission,
+				ExpectedCommission: rebuilt.TotalCommission,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
@@ -0,0 +1,88 @@
+package ledger
+
+import (
+	"context"
+	"ledger-service/internal/core/services/journal"
+	"ledger-service/internal/core/types"
+	"testing"
+	"time"
+)
+
+func TestRebuildBalances(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	customer := types.User{Id: "customer-1", Type: types.CUSTOMER}
+	restaurant := types.User{Id: "restaurant-1", Type: types.RESTAURANT}
+
+	posted := func(tx types.Transaction) types.Transaction {
+		tx.Status = types.POSTED
+		tx.Currency = "EUR"
+		tx.CreatedAt = at
+		tx.PostedAt = &at
+		return tx
+	}
+	transactions := &fakeTransactions{transactions: []types.Transaction{
+		posted(types.Transaction{Id: "deposit-1", Type: types.DEPOSIT, Amount: 5000, Customer: customer}),
+		posted(types.Transaction{Id: "purchase-1", Type: types.PURCHASE, Amount: 2000, Customer: customer, Restaurant: restaurant, Escrowed: true}),
+		posted(types.Transaction{Id: "commission-1", Type: types.COMMISSION, Amount: 100, Restaurant: restaurant, Platform: &types.User{Id: "platform:revenue"}, RelatedTransaction: "purchase-1"}),
+		// Only reserves funds, which are carried over from the live balance.
+		posted(types.Transaction{Id: "hold-1", Type: types.HOLD, Amount: 700, Customer: customer}),
+	}}
+	balances := &fakeBalances{balances: []types.Balance{
+		{UserId: "customer-1", Currency: "EUR", Amount: 3000, Reserved: 700, Sequence: 2},
+		{UserId: journal.EXTERNAL_FUNDING_ACCOUNT, Currency: "EUR", Amount: -5000, Sequence: 1},
+		// Escrow lost by an earlier bug.
+		{UserId: "restaurant-1", Currency: "EUR", Amount: -100, Escrow: 1500, TotalCommission: 100, Sequence: 2},
+		{UserId: "platform:revenue", Currency: "EUR", Amount: 100, TotalCommission: 100, Sequence: 1},
+		// Never had postings.
+		{UserId: "customer-2", Currency: "EUR", OverdraftLimit: 1000},
+	}}
+	shadow := &fakeBalances{}
+	shadowPostings := &fakePostings{}
+
+	// The live journal is empty: postings are made again from the transactions.
+	report, err := RebuildBalances(context.Background(), Repositories{
+		Transactions: transactions,
+		Balances:     balances,
+		Postings:     &fakePostings{},
+	}, Config{PlatformAccount: "platform:revenue"}, shadow, shadowPostings)
+	if err != nil {
+		t.Fatalf("RebuildBalances returned error: %v", err)
+	}
+
+	if report.Transactions != 3 || report.Postings != 6 || report.Wallets != 5 {
+		t.Errorf("rebuilt %d transactions, %d postings and %d wallets, want 3, 6 and 5", report.Transactions, report.Postings, report.Wallets)
+	}
+	if len(shadowPostings.postings) != 6 {
+		t.Fatalf("saved %d postings, want 6", len(shadowPostings.postings))
+	}
+	for _, p := range shadowPostings.postings {
+		if p.TransactionId == "hold-1" {
+			t.Errorf("saved posting %+v for the hold", p)
+		}
+	}
+	if p := shadowPostings.postings[2]; p.Account != "customer-1" || p.Sequence != 2 || p.BalanceAfter == nil || *p.BalanceAfter != 3000 {
+		t.Errorf("purchase posting = %+v, want the second on customer-1 leaving 3000", p)
+	}
+
+	want := map[string]types.Balance{
+		"customer-1":                     {Amount: 3000, Reserved: 700},
+		journal.EXTERNAL_FUNDING_ACCOUNT: {Amount: -5000},
+		"restaurant-1":                   {Amount: -100, Escrow: 2000, TotalCommission: 100},
+		"platform:revenue":               {Amount: 100, TotalCommission: 100},
+		"customer-2":                     {OverdraftLimit: 1000},
+	}
+	for account, w := range want {
+		got := *shadow.wallet(account, "EUR")
+		if got.Amount != w.Amount || got.Escrow != w.Escrow || got.TotalCommission != w.TotalCommission || got.Reserved != w.Reserved || got.OverdraftLimit != w.OverdraftLimit {
+			t.Errorf("rebuilt %s = %+v, want %+v", account, got, w)
+		}
+	}
+
+	wantChanged := types.BalanceDrift{Account: "restaurant-1", Currency: "EUR", StoredAmount: -100, ExpectedAmount: -100, StoredEscrow: 1500, ExpectedEscrow: 2000, StoredCommission: 100, ExpectedCommission: 100}
+	if len(report.Changed) != 1 || report.Changed[0] != wantChanged {
+		t.Errorf("changed %+v, want only %+v", report.Changed, wantChanged)
+	}
+	if live := *balances.wallet("restaurant-1", "EUR"); live.Escrow != 1500 {
+		t.Errorf("live escrow of restaurant-1 = %d, want it left at 1500", live.Escrow)
+	}
+}
@@ -0,0 +1,248 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"sort"
+	"time"
+)
+
+type wallet struct {
+	account  string
+	currency types.Currency
+}
+
+// Reconcile checks every wallet's stored balance, escrow and total
+// commission against the ones recomputed from the posted transactions, and
+// reports the wallets that drifted. With repair, drifted wallets are
+// corrected by the difference. Reservations and overdraft limits do not come
+// from posted transactions and are not checked. Only one reconciliation runs
+// at a time; others fail with types.ErrReconciliationRunning.
+//
+// The journal entry of each transaction is made again with the current
+// journal code, so postings that were stored wrong show up as drift too.
+// Transactions keep being applied meanwhile, so only those posted
+// snapshotLag ago or earlier are replayed, and the postings booked since are
+// added to them as they are stored.
+func (s *Service) Reconcile(ctx context.Context, repair bool) (types.ReconciliationReport, error) {
+	if !s.reconciling.CompareAndSwap(false, true) {
+		return types.ReconciliationReport{}, types.ErrReconciliationRunning
+	}
+	defer s.reconciling.Store(false)
+
+	report := types.ReconciliationReport{
+		StartedAt: time.Now(),
+		Repair:    repair,
+		Drifts:    []types.BalanceDrift{},
+	}
+
+	cutoff := report.StartedAt.Add(-snapshotLag)
+	replayed, err := s.replayJournal(ctx, cutoff)
+	if err != nil {
+		return report, err
+	}
+
+	since, err := s.postingRepo.Totals(ctx, "", "", cutoff, time.Time{})
+	if err != nil {
+		return report, err
+	}
+	expected := make(map[wallet]types.Balance, len(replayed))
+	for key, balance := range replayed {
+		expected[key] = balance
+	}
+	for _, total := range since {
+		key := wallet{total.Account, total.Currency}
+		expected[key] = addTotal(expected[key], total)
+	}
+
+	suspects := []wallet{}
+	err = s.balanceRepo.ForEach(ctx, func(stored types.Balance) error {
+		report.Wallets++
+		key := wallet{stored.UserId, stored.Currency}
+		if drifted(stored, expected[key]) {
+			suspects = append(suspects, key)
+		}
+		delete(expected, key)
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	// Wallets with postings but no stored balance.
+	for key, want := range expected {
+		report.Wallets++
+		if drifted(types.Balance{}, want) {
+			suspects = append(suspects, key)
+		}
+	}
+
+	sort.Slice(suspects, func(i, j int) bool {
+		if suspects[i].account != suspects[j].account {
+			return suspects[i].account < suspects[j].account
+		}
+		return suspects[i].currency < suspects[j].currency
+	})
+
+	// Balances and postings kept changing while they were read above, so
+	// each suspect is checked again with both read as of one point in time
+	// before it is reported.
+	for _, key := range suspects {
+		drift, found, err := s.reconcileWallet(ctx, key, replayed[key], cutoff, repair)
+		if err != nil {
+			return report, err
+		}
+		if !found {
+			continue
+		}
+
+		s.logger.Warn("Balance drift detected",
+			"account", drift.Account,
+			"currency", drift.Currency,
+			"storedAmount", drift.StoredAmount,
+			"expectedAmount", drift.ExpectedAmount,
+			"storedEscrow", drift.StoredEscrow,
+			"expectedEscrow", drift.ExpectedEscrow,
+			"storedCommission", drift.StoredCommission,
+			"expectedCommission", drift.ExpectedCommission,
+			"repaired", drift.Repaired)
+		report.Drifts = append(report.Drifts, drift)
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// replayJournal recomputes every wallet from the journal entries of the
+// transactions posted up to cutoff.
+func (s *Service) replayJournal(ctx context.Context, cutoff time.Time) (map[wallet]types.Balance, error) {
+	balances := map[wallet]types.Balance{}
+	err := s.transactionRepo.ForEachPosted(ctx, func(tx types.Transaction) error {
+		if tx.PostedAt != nil && tx.PostedAt.After(cutoff) {
+			return nil
+		}
+
+		postings, err := s.entry(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("transaction %s: %w", tx.Id, err)
+		}
+		for _, posting := range postings {
+			key := wallet{posting.Account, posting.Currency}
+			balances[key] = book(balances[key], posting)
+		}
+		return nil
+	})
+	return balances, err
+}
+
+// reconcileWallet compares the wallet with replayed, what the transactions
+// posted up to cutoff add up to, and the postings booked on it since, inside
+// a database transaction and, with repair, corrects it there. It returns
+// false if the wallet did not drift.
+func (s *Service) reconcileWallet(ctx context.Context, key wallet, replayed types.Balance, cutoff time.Time, repair bool) (types.BalanceDrift, bool, error) {
+	var drift types.BalanceDrift
+	found := false
+
+	err := s.balanceRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		found = false
+
+		stored, err := s.balanceRepo.GetBalance(ctx, key.account, key.currency)
+		if err != nil {
+			return err
+		}
+
+		since, err := s.postingRepo.Totals(ctx, key.account, key.currency, cutoff, time.Time{})
+		if err != nil {
+			return err
+		}
+		want := replayed
+		for _, total := range since {
+			want = addTotal(want, total)
+		}
+
+		if !drifted(stored, want) {
+			return nil
+		}
+		found = true
+		drift = types.BalanceDrift{
+			Account:            key.account,
+			Currency:           key.currency,
+			StoredAmount:       stored.Amount,
+			ExpectedAmount:     want.Amount,
+			StoredEscrow:       stored.Escrow,
+			ExpectedEscrow:     want.Escrow,
+			StoredCommission:   stored.TotalCommission,
+			ExpectedCommission: want.TotalCommission,
+		}
+		if !repair {
+			return nil
+		}
+
+		if diff := want.Amount - stored.Amount; diff != 0 {
+			if _, err := s.balanceRepo.UpdateBalance(ctx, key.account, key.currency, diff); err != nil {
+				return err
+			}
+		}
+		if diff := want.Escrow - stored.Escrow; diff != 0 {
+			if _, err := s.balanceRepo.UpdateEscrow(ctx, key.account, key.currency, diff); err != nil {
+				return err
+			}
+		}
+		if diff := want.TotalCommission - stored.TotalCommission; diff != 0 {
+			if err := s.balanceRepo.UpdateTotalCommission(ctx, key.account, key.currency, diff); err != nil {
+				return err
+			}
+		}
+		drift.Repaired = true
+		return nil
+	})
+	return drift, found, err
+}
+
+// addTotal adds the postings totalled in total to the balance.
+func addTotal(balance types.Balance, total types.BalanceSnapshot) types.Balance {
+	balance.Amount += total.Amount
+	balance.Escrow += total.Escrow
+	balance.TotalCommission += total.Commission
+	return balance
+}
+
+func drifted(stored, want types.Balance) bool {
+	return stored.Amount != want.Amount ||
+		stored.Escrow != want.Escrow ||
+		stored.TotalCommission != want.TotalCommission
+}
+
+// reconcileBalances runs a reconciliation every
+// Config.ReconciliationInterval, repairing drift if
+// Config.ReconciliationRepair is set.
+func (s *Service) reconcileBalances() {
+	ticker := time.NewTicker(s.config.ReconciliationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(s.ctx, 30*time.Minute)
+			report, err := s.Reconcile(ctx, s.config.ReconciliationRepair)
+			cancel()
+
+			switch {
+			case errors.Is(err, types.ErrReconciliationRunning):
+				s.logger.Info("Skipping scheduled reconciliation, one is already running")
+			case err != nil:
+				s.logger.Error("Balance reconciliation failed", "error", err.Error())
+			default:
+				s.logger.Info("Balance reconciliation finished",
+					"wallets", report.Wallets,
+					"drifts", len(report.Drifts),
+					"repair", report.Repair,
+					"duration", report.FinishedAt.Sub(report.StartedAt).String())
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package ledger
+
+import (
+	"context"
+	"ledger-service/internal/core/services/journal"
+	"ledger-service/internal/core/types"
+	"testing"
+	"time"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	customer := types.User{Id: "customer-1", Type: types.CUSTOMER}
+	restaurant := types.User{Id: "restaurant-1", Type: types.RESTAURANT}
+
+	posted := func(tx types.Transaction, at time.Time) types.Transaction {
+		tx.Status = types.POSTED
+		tx.Currency = "EUR"
+		tx.CreatedAt = at
+		tx.PostedAt = &at
+		return tx
+	}
+	transactions := &fakeTransactions{transactions: []types.Transaction{
+		posted(types.Transaction{Id: "deposit-1", Type: types.DEPOSIT, Amount: 5000, Customer: customer}, earlier),
+		posted(types.Transaction{Id: "purchase-1", Type: types.PURCHASE, Amount: 2000, Customer: customer, Restaurant: restaurant, Escrowed: true}, earlier),
+		// Earned by the platform account of the time, not the one configured now.
+		posted(types.Transaction{Id: "commission-1", Type: types.COMMISSION, Amount: 100, Restaurant: restaurant, Platform: &types.User{Id: "platform:old"}, RelatedTransaction: "purchase-1"}, earlier),
+		posted(types.Transaction{Id: "refund-1", Type: types.REFUND, Amount: 500, Customer: customer, Restaurant: restaurant, RelatedTransaction: "purchase-1", Escrowed: true}, earlier),
+		posted(types.Transaction{Id: "reversal-1", Type: types.REVERSAL, Amount: 500, Customer: customer, Restaurant: restaurant, RelatedTransaction: "refund-1", Escrowed: true}, earlier),
+		{Id: "hold-1", Type: types.HOLD, Status: types.POSTED, Amount: 700, Currency: "EUR", Customer: customer, CreatedAt: earlier},
+		// Posted after the cutoff; only its postings are read.
+		posted(types.Transaction{Id: "deposit-2", Type: types.DEPOSIT, Amount: 300, Customer: customer}, now),
+	}}
+	postings := &fakePostings{postings: []types.Posting{
+		{Id: "deposit-2-0", TransactionId: "deposit-2", Account: "customer-1", Currency: "EUR", Amount: 300, PostedAt: now},
+		{Id: "deposit-2-1", TransactionId: "deposit-2", Account: journal.EXTERNAL_FUNDING_ACCOUNT, Currency: "EUR", Amount: -300, PostedAt: now},
+	}}
+	balances := &fakeBalances{balances: []types.Balance{
+		{UserId: "customer-1", Currency: "EUR", Amount: 3300, Reserved: 700},
+		{UserId: journal.EXTERNAL_FUNDING_ACCOUNT, Currency: "EUR", Amount: -5300},
+		// The reversal of the refund was not put back into escrow.
+		{UserId: "restaurant-1", Currency: "EUR", Amount: -100, Escrow: 1500, TotalCommission: 100},
+		// Commission counted as paid rather than earned.
+		{UserId: "platform:old", Currency: "EUR", Amount: 100, TotalCommission: -100},
+	}}
+
+	s := NewService(Repositories{Transactions: transactions, Balances: balances, Postings: postings}, nil, Config{PlatformAccount: "platform:new"})
+
+	report, err := s.Reconcile(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if report.Wallets != 4 {
+		t.Errorf("checked %d wallets, want 4", report.Wallets)
+	}
+
+	want := []types.BalanceDrift{
+		{Account: "platform:old", Currency: "EUR", StoredAmount: 100, ExpectedAmount: 100, StoredCommission: -100, ExpectedCommission: 100},
+		{Account: "restaurant-1", Currency: "EUR", StoredAmount: -100, ExpectedAmount: -100, StoredEscrow: 1500, ExpectedEscrow: 2000, StoredCommission: 100, ExpectedCommission: 100},
+	}
+	if len(report.Drifts) != len(want) {
+		t.Fatalf("got drifts %+v, want %+v", report.Drifts, want)
+	}
+	for i, drift := range report.Drifts {
+		if drift != want[i] {
+			t.Errorf("drift %d = %+v, want %+v", i, drift, want[i])
+		}
+	}
+
+	report, err = s.Reconcile(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Reconcile with repair returned error: %v", err)
+	}
+	for _, drift := range report.Drifts {
+		if !drift.Repaired {
+			t.Errorf("drift on %s was not repaired", drift.Account)
+		}
+	}
+
+	report, err = s.Reconcile(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Reconcile after repair returned error: %v", err)
+	}
+	if len(report.Drifts) != 0 {
+		t.Errorf("drifts left after repair: %+v", report.Drifts)
+	}
+	if customer, _ := balances.GetBalance(context.Background(), "customer-1", "EUR"); customer.Reserved != 700 {
+		t.Errorf("customer reservation = %d, want it left at 700", customer.Reserved)
+	}
+}
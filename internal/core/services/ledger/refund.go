@@ -0,0 +1,254 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"time"
+)
+
+// RefundPurchase gives amount of a posted purchase back to the customer.
+// Like any transaction the refund is applied asynchronously, and the
+// commission charged on the refunded part is returned to the restaurant when
+// it is.
+func (s *Service) RefundPurchase(ctx context.Context, purchaseId string, amount types.Money, idempotencyKey string) (types.Transaction, bool, error) {
+	purchase, err := s.transactionRepo.GetById(ctx, purchaseId)
+	if err != nil {
+		return types.Transaction{}, false, err
+	}
+
+	if purchase.Type != types.PURCHASE || purchase.Status != types.POSTED {
+		return types.Transaction{}, false, fmt.Errorf("%w: transaction %s is a %s %s",
+			types.ErrNotRefundable, purchase.Id, purchase.Status, purchase.Type)
+	}
+	if purchase.Reversed {
+		return types.Transaction{}, false, fmt.Errorf("%w: purchase %s was reversed", types.ErrNotRefundable, purchase.Id)
+	}
+
+	refund := types.Transaction{
+		Type:               types.REFUND,
+		Amount:             amount,
+		Currency:           purchase.Currency,
+		Customer:           purchase.Customer,
+		Restaurant:         purchase.Restaurant,
+		RelatedTransaction: purchase.Id,
+		CreatedAt:          time.Now(),
+	}
+
+	return s.SaveTransaction(ctx, refund, idempotencyKey)
+}
+
+// splitRefund records, on a refund of a purchase split into legs, the part
+// of each leg it gives back, so that the refund is taken from every party the
+// purchase paid in proportion to what they still hold of it. Refunds are
+// applied one at a time, so every earlier refund of the purchase is already
+// posted. It is expected to run inside the transaction that applies the
+// refund, before its escrow is booked.
+func (s *Service) splitRefund(ctx context.Context, refund *types.Transaction) error {
+	if refund.Type != types.REFUND {
+		return nil
+	}
+
+	purchase, err := s.transactionRepo.GetById(ctx, refund.RelatedTransaction)
+	if err != nil || len(purchase.Legs) == 0 {
+		return err
+	}
+
+	_, earlier, err := s.purchaseRelated(ctx, purchase.Id, refund.Id)
+	if err != nil {
+		return err
+	}
+
+	legs, err := refundLegs(purchase, earlier, refund.Amount)
+	if err != nil {
+		return err
+	}
+	if err := s.transactionRepo.SetLegs(ctx, refund.Id, legs); err != nil {
+		return err
+	}
+	refund.Legs = legs
+	return nil
+}
+
+// buildCommissionRefund returns the commission charged on the refunded part
+// of the purchase's commission base. It is derived from the total refunded so
+// far, so rounding never drifts across partial refunds and a full refund
+// returns exactly the commission charged. Refunds are applied one at a time,
+// so every earlier refund of the purchase is already posted.
+func (s *Service) buildCommissionRefund(ctx context.Context, refund types.Transaction) (types.Transaction, error) {
+	purchase, err := s.transactionRepo.GetById(ctx, refund.RelatedTransaction)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	commission, earlier, err := s.purchaseRelated(ctx, purchase.Id, refund.Id)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	base := purchase.CommissionBase()
+	if commission.Id == "" || base == 0 {
+		return types.Transaction{}, nil
+	}
+
+	amount, err := returnedCommission(commission.Amount, base, commissionBaseRefunded(purchase, earlier), refund.CommissionBase())
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	return types.Transaction{
+		Type:               types.COMMISSION_REFUND,
+		Amount:             amount,
+		Currency:           refund.Currency,
+		Restaurant:         refund.Restaurant,
+		Platform:           commission.Platform,
+		RelatedTransaction: refund.Id,
+		CommissionRate:     commission.CommissionRate,
+		CreatedAt:          time.Now(),
+	}, nil
+}
+
+// purchaseRelated returns the commission charged on the purchase and its
+// posted refunds, other than the one being applied and those reversed since.
+func (s *Service) purchaseRelated(ctx context.Context, purchaseId, refundId string) (types.Transaction, []types.Transaction, error) {
+	related, err := s.transactionRepo.GetRelated(ctx, purchaseId)
+	if err != nil {
+		return types.Transaction{}, nil, err
+	}
+
+	var commission types.Transaction
+	refunds := []types.Transaction{}
+	for _, tx := range related {
+		switch {
+		case tx.Type == types.COMMISSION:
+			commission = tx
+		case tx.Type == types.REFUND && tx.Status == types.POSTED && !tx.Reversed && tx.Id != refundId:
+			refunds = append(refunds, tx)
+		}
+	}
+	return commission, refunds, nil
+}
+
+// legsOf returns the purchase's legs; a purchase without legs is a single
+// commissionable leg credited to the restaurant.
+func legsOf(purchase types.Transaction) []types.Leg {
+	if len(purchase.Legs) > 0 {
+		return purchase.Legs
+	}
+	return []types.Leg{{Payee: purchase.Restaurant, Amount: purchase.Amount, Commissionable: true}}
+}
+
+// legsLeft returns what is left of each of the purchase's legs after the
+// earlier refunds. Refunds without legs were taken from the restaurant alone.
+func legsLeft(purchase types.Transaction, earlier []types.Transaction) []types.Money {
+	legs := legsOf(purchase)
+	left := make([]types.Money, len(legs))
+	for i, leg := range legs {
+		left[i] = leg.Amount
+	}
+
+	take := func(matches func(types.Leg) bool, amount types.Money) {
+		for i, leg := range legs {
+			if amount == 0 {
+				return
+			}
+			if matches(leg) {
+				taken := min(amount, left[i])
+				left[i] -= taken
+				amount -= taken
+			}
+		}
+	}
+
+	for _, refund := range earlier {
+		if len(refund.Legs) == 0 {
+			take(func(leg types.Leg) bool { return leg.Payee.Id == purchase.Restaurant.Id }, refund.Amount)
+			continue
+		}
+		for _, refunded := range refund.Legs {
+			take(func(leg types.Leg) bool {
+				return leg.Component == refunded.Component && leg.Payee.Id == refunded.Payee.Id
+			}, refunded.Amount)
+		}
+	}
+	return left
+}
+
+// commissionBaseRefunded is the part of the purchase's commission base given
+// back by the earlier refunds.
+func commissionBaseRefunded(purchase types.Transaction, earlier []types.Transaction) types.Money {
+	var refunded types.Money
+	for i, left := range legsLeft(purchase, earlier) {
+		if leg := legsOf(purchase)[i]; leg.Commissionable {
+			refunded += leg.Amount - left
+		}
+	}
+	return refunded
+}
+
+// refundLegs splits a refund of amount across the purchase's legs in
+// proportion to what is left of each after the earlier refunds, rounding
+// down; the cents left over by rounding go to the first legs that still have
+// something left. A refund of everything that is left gives back exactly what
+// is left of every leg.
+func refundLegs(purchase types.Transaction, earlier []types.Transaction, amount types.Money) ([]types.Leg, error) {
+	left := legsLeft(purchase, earlier)
+
+	var total types.Money
+	for _, l := range left {
+		total += l
+	}
+	if amount > total {
+		return nil, fmt.Errorf("%w: %d of %d left", types.ErrRefundExceedsPurchase, amount, total)
+	}
+
+	shares := make([]types.Money, len(left))
+	remainder := amount
+	for i, l := range left {
+		share, err := l.MulDiv(int64(amount), int64(total), types.RoundDown)
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = share
+		remainder -= share
+	}
+	for i := range shares {
+		if remainder == 0 {
+			break
+		}
+		if shares[i] < left[i] {
+			shares[i]++
+			remainder--
+		}
+	}
+
+	legs := []types.Leg{}
+	for i, leg := range legsOf(purchase) {
+		if shares[i] == 0 {
+			continue
+		}
+		legs = append(legs, types.Leg{
+			Component:      leg.Component,
+			Payee:          leg.Payee,
+			Amount:         shares[i],
+			Commissionable: leg.Commissionable,
+		})
+	}
+	return legs, nil
+}
+
+// returnedCommission is the commission given back by refunding amount of a
+// commission base of base once refundedBefore was refunded already: the
+// commission on everything refunded so far, less what earlier refunds gave
+// back.
+func returnedCommission(commission, base, refundedBefore, amount types.Money) (types.Money, error) {
+	before, err := commission.MulDiv(int64(refundedBefore), int64(base), COMMISSION_ROUNDING)
+	if err != nil {
+		return 0, err
+	}
+	after, err := commission.MulDiv(int64(refundedBefore+amount), int64(base), COMMISSION_ROUNDING)
+	if err != nil {
+		return 0, err
+	}
+	return after - before, nil
+}
@@ -0,0 +1,139 @@
+package ledger
+
+import (
+	"errors"
+	"ledger-service/internal/core/types"
+	"slices"
+	"testing"
+)
+
+func TestReturnedCommission(t *testing.T) {
+	tests := []struct {
+		name       string
+		commission types.Money
+		total      types.Money
+		refunds    []types.Money
+		want       []types.Money
+	}{
+		{"full refund", 150, 3000, []types.Money{3000}, []types.Money{150}},
+		{"half refund", 150, 3000, []types.Money{1500}, []types.Money{75}},
+		{"equal thirds", 100, 3000, []types.Money{1000, 1000, 1000}, []types.Money{33, 34, 33}},
+		{"many small refunds", 7, 1000, []types.Money{100, 100, 100, 100, 100, 100, 100, 100, 100, 100}, []types.Money{1, 0, 1, 1, 1, 0, 1, 1, 0, 1}},
+		{"refund too small to return commission", 50, 10000, []types.Money{99}, []types.Money{0}},
+		{"no commission", 0, 3000, []types.Money{1000, 2000}, []types.Money{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var refunded, returned types.Money
+			for i, refund := range tt.refunds {
+				got, err := returnedCommission(tt.commission, tt.total, refunded, refund)
+				if err != nil {
+					t.Fatalf("refund %d: %v", i, err)
+				}
+				if got != tt.want[i] {
+					t.Errorf("refund %d of %d returned %d, want %d", i, refund, got, tt.want[i])
+				}
+				refunded += refund
+				returned += got
+			}
+
+			if refunded == tt.total && returned != tt.commission {
+				t.Errorf("full refund returned %d in total, want the whole commission %d", returned, tt.commission)
+			}
+		})
+	}
+}
+
+func splitPurchase() types.Transaction {
+	restaurant := types.User{Id: "restaurant-1"}
+	return types.Transaction{
+		Id: "purchase-1", Type: types.PURCHASE, Amount: 1500, Restaurant: restaurant,
+		Legs: []types.Leg{
+			{Component: types.ITEMS, Payee: restaurant, Amount: 1200, Commissionable: true},
+			{Component: types.DELIVERY_FEE, Payee: types.User{Id: "courier-1"}, Amount: 200},
+			{Component: types.SERVICE_FEE, Payee: types.User{Id: "platform:revenue"}, Amount: 100},
+		},
+	}
+}
+
+func TestRefundLegs(t *testing.T) {
+	partial := types.Transaction{Type: types.REFUND, Amount: 500, Legs: []types.Leg{
+		{Component: types.ITEMS, Payee: types.User{Id: "restaurant-1"}, Amount: 401},
+		{Component: types.DELIVERY_FEE, Payee: types.User{Id: "courier-1"}, Amount: 66},
+		{Component: types.SERVICE_FEE, Payee: types.User{Id: "platform:revenue"}, Amount: 33},
+	}}
+	legacy := types.Transaction{Type: types.REFUND, Amount: 300}
+
+	tests := []struct {
+		name    string
+		earlier []types.Transaction
+		amount  types.Money
+		// want is the amount given back of each leg of the purchase.
+		want []types.Money
+	}{
+		{"full refund", nil, 1500, []types.Money{1200, 200, 100}},
+		{"pro rata with the rounding to the first leg", nil, 500, []types.Money{401, 66, 33}},
+		{"rest after a partial refund", []types.Transaction{partial}, 1000, []types.Money{799, 134, 67}},
+		{"refund too small for every leg", nil, 1, []types.Money{1, 0, 0}},
+		{"legacy refund taken from the restaurant", []types.Transaction{legacy}, 1200, []types.Money{900, 200, 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			legs, err := refundLegs(splitPurchase(), tt.earlier, tt.amount)
+			if err != nil {
+				t.Fatalf("refundLegs returned error: %v", err)
+			}
+
+			got := make([]types.Money, len(splitPurchase().Legs))
+			var total types.Money
+			for _, leg := range legs {
+				for i, purchased := range splitPurchase().Legs {
+					if leg.Component == purchased.Component && leg.Payee.Id == purchased.Payee.Id {
+						got[i] += leg.Amount
+						if leg.Commissionable != purchased.Commissionable {
+							t.Errorf("leg %s is commissionable %t, want %t", leg.Component, leg.Commissionable, purchased.Commissionable)
+						}
+					}
+				}
+				total += leg.Amount
+			}
+			if total != tt.amount {
+				t.Errorf("legs total %d, want %d", total, tt.amount)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("refundLegs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := refundLegs(splitPurchase(), []types.Transaction{partial}, 1001); !errors.Is(err, types.ErrRefundExceedsPurchase) {
+		t.Errorf("refundLegs past the purchase error = %v, want ErrRefundExceedsPurchase", err)
+	}
+}
+
+func TestCommissionBaseRefunded(t *testing.T) {
+	tests := []struct {
+		name     string
+		purchase types.Transaction
+		earlier  []types.Transaction
+		want     types.Money
+	}{
+		{"no refunds", splitPurchase(), nil, 0},
+		{"only the commissionable legs count", splitPurchase(), []types.Transaction{{Amount: 500, Legs: []types.Leg{
+			{Component: types.ITEMS, Payee: types.User{Id: "restaurant-1"}, Amount: 401},
+			{Component: types.DELIVERY_FEE, Payee: types.User{Id: "courier-1"}, Amount: 99},
+		}}}, 401},
+		{"legacy refund", splitPurchase(), []types.Transaction{{Amount: 300}}, 300},
+		{"purchase without legs", types.Transaction{Amount: 1000, Restaurant: types.User{Id: "restaurant-1"}}, []types.Transaction{{Amount: 250}, {Amount: 100}}, 350},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commissionBaseRefunded(tt.purchase, tt.earlier); got != tt.want {
+				t.Errorf("commissionBaseRefunded = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"slices"
+	"time"
+)
+
+// ReverseTransaction voids a posted transaction. Once the reversal is posted
+// every balance the original changed, including total_commission, is back
+// where it was: the commission charged on a purchase, or given back with a
+// refund, is reversed along with it, and a reversed refund can be refunded
+// again. A transaction can be reversed once; purchases that were partly
+// refunded and reversals themselves cannot be reversed.
+func (s *Service) ReverseTransaction(ctx context.Context, id, reason, operator, idempotencyKey string) (types.Transaction, bool, error) {
+	original, err := s.transactionRepo.GetById(ctx, id)
+	if err != nil {
+		return types.Transaction{}, false, err
+	}
+
+	switch {
+	case original.Type == types.REVERSAL, original.Type == types.HOLD, original.Type == types.ESCROW_RELEASE:
+		return types.Transaction{}, false, fmt.Errorf("%w: transaction %s is a %s", types.ErrNotReversible, id, original.Type)
+	case original.Status != types.POSTED:
+		return types.Transaction{}, false, fmt.Errorf("%w: transaction %s is %s", types.ErrNotReversible, id, original.Status)
+	case original.RefundedAmount > 0:
+		return types.Transaction{}, false, fmt.Errorf("%w: purchase %s was refunded", types.ErrNotReversible, id)
+	}
+
+	reversal := types.Transaction{
+		Type:               types.REVERSAL,
+		Amount:             original.Amount,
+		Currency:           original.Currency,
+		Customer:           original.Customer,
+		Restaurant:         original.Restaurant,
+		Recipient:          original.Recipient,
+		Platform:           original.Platform,
+		Courier:            original.Courier,
+		RelatedTransaction: original.Id,
+		Reason:             reason,
+		Operator:           operator,
+		CreatedAt:          time.Now(),
+	}
+
+	// A reversal accepted earlier with the same key is replayed before the
+	// double reversal guard would reject it.
+	return s.SaveTransaction(ctx, reversal, idempotencyKey)
+}
+
+// reversalPostings returns the inverse of the postings of the transaction
+// the reversal voids.
+func (s *Service) reversalPostings(ctx context.Context, reversal types.Transaction, postedAt time.Time) ([]types.Posting, error) {
+	reversed, err := s.postingRepo.GetForTransaction(ctx, reversal.RelatedTransaction)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := s.transactionRepo.GetById(ctx, reversal.RelatedTransaction)
+	if err != nil {
+		return nil, err
+	}
+	// Refunds applied before they recorded it only show it in their postings.
+	escrowed := original.Escrowed || slices.ContainsFunc(reversed, func(p types.Posting) bool { return p.Escrow })
+	if escrowed {
+		if err := s.reverseEscrow(ctx, reversal, original, reversed); err != nil {
+			return nil, err
+		}
+	}
+	if original.Type == types.REFUND {
+		if err := s.transactionRepo.ReleaseRefund(ctx, original.RelatedTransaction, original.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.journal.Reversal(reversal, reversed, postedAt)
+}
+
+// reverseCommission reverses the commission charged on the purchase, or
+// returned with the refund, that the reversal voids, with a REVERSAL of its
+// own posted right away. Commission that was reversed on its own already is
+// left alone. It is expected to run inside the transaction that applies the
+// reversal.
+func (s *Service) reverseCommission(ctx context.Context, reversal types.Transaction, postedAt time.Time) error {
+	original, err := s.transactionRepo.GetById(ctx, reversal.RelatedTransaction)
+	if err != nil {
+		return err
+	}
+
+	var commissionType types.TransactionType
+	switch original.Type {
+	case types.PURCHASE:
+		commissionType = types.COMMISSION
+	case types.REFUND:
+		commissionType = types.COMMISSION_REFUND
+	default:
+		return nil
+	}
+
+	related, err := s.transactionRepo.GetRelated(ctx, original.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, commission := range related {
+		if commission.Type != commissionType || commission.Status != types.POSTED || commission.Reversed {
+			continue
+		}
+		if err := s.transactionRepo.ClaimReversal(ctx, commission.Id); err != nil {
+			return err
+		}
+
+		commissionReversal := types.Transaction{
+			Type:               types.REVERSAL,
+			Status:             types.POSTED,
+			Amount:             commission.Amount,
+			Currency:           commission.Currency,
+			Restaurant:         commission.Restaurant,
+			Platform:           commission.Platform,
+			RelatedTransaction: commission.Id,
+			Reason:             reversal.Reason,
+			Operator:           reversal.Operator,
+			CreatedAt:          postedAt,
+			PostedAt:           &postedAt,
+		}
+
+		id, err := s.transactionRepo.Save(ctx, commissionReversal)
+		if err != nil {
+			return fmt.Errorf("save commission reversal: %w", err)
+		}
+		commissionReversal.Id = id
+
+		if err := s.updateBalances(ctx, commissionReversal, postedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
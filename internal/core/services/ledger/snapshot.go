@@ -0,0 +1,149 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"slices"
+	"time"
+)
+
+// snapshotLag keeps snapshots this far behind the present. Postings carry the
+// time their transaction started being applied, so a transaction still being
+// applied when a snapshot is taken is covered by the next one.
+const snapshotLag = 5 * time.Minute
+
+// GetBalancesAsOf returns the user's balances as they were at asOf, in every
+// currency they held then or only in the given one. They are computed from
+// the postings booked up to asOf, starting from the latest snapshot taken
+// before it. Reservations and overdraft limits are not historical and are
+// left at zero.
+func (s *Service) GetBalancesAsOf(ctx context.Context, userId string, currency types.Currency, asOf time.Time) ([]types.Balance, error) {
+	currencies := []types.Currency{currency}
+	if currency == "" {
+		wallets, err := s.balanceRepo.GetBalances(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		currencies = []types.Currency{}
+		for _, wallet := range wallets {
+			currencies = append(currencies, wallet.Currency)
+		}
+	} else if !currency.Valid() {
+		return nil, fmt.Errorf("%w: %s", types.ErrUnsupportedCurrency, currency)
+	}
+
+	balances := []types.Balance{}
+	for _, walletCurrency := range currencies {
+		snapshot, err := s.snapshotAt(ctx, userId, walletCurrency, asOf)
+		if err != nil {
+			return nil, err
+		}
+
+		// Wallets opened after asOf did not exist yet.
+		if snapshot.Postings == 0 && currency == "" {
+			continue
+		}
+
+		balances = append(balances, s.balanceFromSnapshot(snapshot))
+	}
+	return balances, nil
+}
+
+// snapshotAt computes the account's balance in currency as of asOf from its
+// latest snapshot and the postings booked since.
+func (s *Service) snapshotAt(ctx context.Context, account string, currency types.Currency, asOf time.Time) (types.BalanceSnapshot, error) {
+	snapshot, found, err := s.snapshotRepo.Latest(ctx, account, currency, asOf)
+	if err != nil {
+		return types.BalanceSnapshot{}, err
+	}
+	if !found {
+		snapshot = types.BalanceSnapshot{Account: account, Currency: currency}
+	}
+
+	deltas, err := s.postingRepo.Totals(ctx, account, currency, snapshot.AsOf, asOf)
+	if err != nil {
+		return types.BalanceSnapshot{}, err
+	}
+	for _, delta := range deltas {
+		snapshot = snapshot.Add(delta)
+	}
+
+	snapshot.AsOf = asOf
+	return snapshot, nil
+}
+
+func (s *Service) balanceFromSnapshot(snapshot types.BalanceSnapshot) types.Balance {
+	return types.Balance{
+		UserId:          snapshot.Account,
+		Currency:        snapshot.Currency,
+		Amount:          snapshot.Amount,
+		Escrow:          snapshot.Escrow,
+		TotalCommission: snapshot.Commission,
+	}
+}
+
+// snapshotBalances takes balance snapshots every
+// Config.BalanceSnapshotInterval.
+func (s *Service) snapshotBalances() {
+	ticker := time.NewTicker(s.config.BalanceSnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(s.ctx, 5*time.Minute)
+			if err := s.takeSnapshots(ctx, time.Now().Add(-snapshotLag)); err != nil {
+				s.logger.Error("Taking balance snapshots failed", "error", err.Error())
+			}
+			cancel()
+		}
+	}
+}
+
+// snapshotBatchSize is how many snapshots are saved at once.
+const snapshotBatchSize = 500
+
+// takeSnapshots snapshots, as of asOf, every account with postings booked
+// since the previous run completed. Snapshots are saved in batches and the run
+// is only recorded once every batch is; a run cut short is taken again from
+// the previous one, saving its batches over the ones already saved.
+func (s *Service) takeSnapshots(ctx context.Context, asOf time.Time) error {
+	last, found, err := s.snapshotRepo.LastAsOf(ctx)
+	if err != nil {
+		return err
+	}
+	if found && !asOf.After(last) {
+		return nil
+	}
+
+	deltas, err := s.postingRepo.Totals(ctx, "", "", last, asOf)
+	if err != nil {
+		return err
+	}
+
+	snapshots := make([]types.BalanceSnapshot, 0, len(deltas))
+	for _, delta := range deltas {
+		base, found, err := s.snapshotRepo.Latest(ctx, delta.Account, delta.Currency, last)
+		if err != nil {
+			return err
+		}
+		if !found {
+			base = types.BalanceSnapshot{Account: delta.Account, Currency: delta.Currency}
+		}
+
+		snapshot := base.Add(delta)
+		snapshot.AsOf = asOf
+		snapshots = append(snapshots, snapshot)
+	}
+
+	for batch := range slices.Chunk(snapshots, snapshotBatchSize) {
+		if err := s.snapshotRepo.SaveMany(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return s.snapshotRepo.MarkTaken(ctx, asOf)
+}
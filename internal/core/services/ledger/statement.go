@@ -0,0 +1,127 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"time"
+)
+
+// statementPageSize is how many postings are read at a time while building a
+// statement.
+const statementPageSize = 1000
+
+// GetRestaurantStatement builds the restaurant's statement for period in
+// currency, or in the default currency if it is empty. Its figures come from
+// the postings booked on the restaurant's account during the period, each
+// classified by the type of the transaction it records, and its opening
+// balance from the balance as of the start of the period.
+func (s *Service) GetRestaurantStatement(ctx context.Context, restaurantId string, currency types.Currency, period types.Period) (types.Statement, error) {
+	if currency == "" {
+		currency = s.config.DefaultCurrency
+	}
+	if !currency.Valid() {
+		return types.Statement{}, fmt.Errorf("%w: %s", types.ErrUnsupportedCurrency, currency)
+	}
+
+	now := time.Now()
+	statement := types.Statement{
+		RestaurantId: restaurantId,
+		Currency:     currency,
+		Period:       period,
+		From:         period.Start(),
+		To:           period.End(),
+		Final:        true,
+	}
+	if !statement.From.Before(now) {
+		return types.Statement{}, fmt.Errorf("%w: %s has not started yet", types.ErrInvalidPeriod, period)
+	}
+	if statement.To.After(now) {
+		statement.To = now
+		statement.Final = false
+	}
+
+	// Balances as of an instant include the postings booked at it, so the
+	// opening balance is taken just before the period starts.
+	opening, err := s.snapshotAt(ctx, restaurantId, currency, statement.From.Add(-time.Nanosecond))
+	if err != nil {
+		return types.Statement{}, err
+	}
+	statement.OpeningBalance = opening.Amount + opening.Escrow
+	statement.OpeningEscrow = opening.Escrow
+	statement.ClosingBalance = statement.OpeningBalance
+	statement.ClosingEscrow = statement.OpeningEscrow
+
+	purchases := map[string]bool{}
+	var after *types.Cursor
+	for {
+		postings, err := s.postingRepo.GetHistory(ctx, restaurantId, currency, statement.From, statement.To, after, statementPageSize)
+		if err != nil {
+			return types.Statement{}, err
+		}
+		if len(postings) == 0 {
+			break
+		}
+
+		transactionTypes, err := s.transactionTypes(ctx, postings)
+		if err != nil {
+			return types.Statement{}, err
+		}
+
+		for _, posting := range postings {
+			switch transactionTypes[posting.TransactionId] {
+			case types.PURCHASE:
+				statement.GrossPurchases += posting.Amount
+				purchases[posting.TransactionId] = true
+			case types.COMMISSION, types.COMMISSION_REFUND:
+				statement.CommissionDeducted -= posting.Amount
+			case types.REFUND:
+				statement.Refunds -= posting.Amount
+			case types.PAYOUT:
+				statement.Payouts -= posting.Amount
+			case types.ESCROW_RELEASE:
+				// Moves proceeds from escrow to the balance; the total is unchanged.
+			default:
+				statement.Adjustments += posting.Amount
+			}
+
+			statement.ClosingBalance += posting.Amount
+			if posting.Escrow {
+				statement.ClosingEscrow += posting.Amount
+			}
+		}
+
+		if len(postings) < statementPageSize {
+			break
+		}
+		last := postings[len(postings)-1]
+		after = &types.Cursor{At: last.PostedAt, Id: last.Id}
+	}
+
+	statement.Purchases = int64(len(purchases))
+	return statement, nil
+}
+
+// transactionTypes returns the type of the transaction of each posting, by
+// transaction id.
+func (s *Service) transactionTypes(ctx context.Context, postings []types.Posting) (map[string]types.TransactionType, error) {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, posting := range postings {
+		if !seen[posting.TransactionId] {
+			seen[posting.TransactionId] = true
+			ids = append(ids, posting.TransactionId)
+		}
+	}
+
+	transactions, err := s.transactionRepo.GetByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionTypes := make(map[string]types.TransactionType, len(transactions))
+	for _, t := range transactions {
+		transactionTypes[t.Id] = t.Type
+	}
+	return transactionTypes, nil
+}
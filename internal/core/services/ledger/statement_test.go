@@ -0,0 +1,97 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"testing"
+	"time"
+)
+
+func TestGetRestaurantStatement(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2026, month, d, 12, 0, 0, 0, time.UTC) }
+
+	transactions := &fakeTransactions{}
+	postings := &fakePostings{}
+	post := func(id string, txType types.TransactionType, at time.Time, amount types.Money, escrow bool) {
+		transactions.add(types.Transaction{Id: id, Type: txType})
+		postings.postings = append(postings.postings, types.Posting{
+			Id:            fmt.Sprintf("%s-%d", id, len(postings.postings)),
+			TransactionId: id,
+			Account:       "restaurant-1",
+			Currency:      "EUR",
+			Amount:        amount,
+			Escrow:        escrow,
+			PostedAt:      at,
+		})
+	}
+
+	// Before March: a purchase, its commission and the release of its
+	// proceeds leave 950 on the balance.
+	post("purchase-0", types.PURCHASE, day(time.February, 10), 1000, true)
+	post("commission-0", types.COMMISSION, day(time.February, 10), -50, false)
+	post("release-0", types.ESCROW_RELEASE, day(time.February, 20), -1000, true)
+	post("release-0", types.ESCROW_RELEASE, day(time.February, 20), 1000, false)
+
+	// March: a purchase with two legs for the restaurant, partly refunded
+	// from escrow before the rest is released.
+	post("purchase-1", types.PURCHASE, day(time.March, 1), 1000, true)
+	post("purchase-1", types.PURCHASE, day(time.March, 1), 200, true)
+	post("commission-1", types.COMMISSION, day(time.March, 1), -60, false)
+	post("refund-1", types.REFUND, day(time.March, 5), -400, true)
+	post("commission-refund-1", types.COMMISSION_REFUND, day(time.March, 5), 20, false)
+	post("release-1", types.ESCROW_RELEASE, day(time.March, 10), -800, true)
+	post("release-1", types.ESCROW_RELEASE, day(time.March, 10), 800, false)
+	post("payout-1", types.PAYOUT, day(time.March, 15), -500, false)
+	post("reversal-1", types.REVERSAL, day(time.March, 20), 50, false)
+	post("purchase-2", types.PURCHASE, day(time.March, 31), 300, true)
+
+	// After March.
+	post("purchase-3", types.PURCHASE, day(time.April, 2), 999, true)
+
+	// Another currency.
+	postings.postings = append(postings.postings, types.Posting{
+		Id: "purchase-4-0", TransactionId: "purchase-4", Account: "restaurant-1", Currency: "USD", Amount: 700, PostedAt: day(time.March, 3),
+	})
+	transactions.add(types.Transaction{Id: "purchase-4", Type: types.PURCHASE})
+
+	s := &Service{
+		transactionRepo: transactions,
+		postingRepo:     postings,
+		snapshotRepo:    fakeSnapshots{},
+		config:          Config{DefaultCurrency: "EUR"},
+	}
+
+	statement, err := s.GetRestaurantStatement(context.Background(), "restaurant-1", "", types.Period{Year: 2026, Month: time.March})
+	if err != nil {
+		t.Fatalf("GetRestaurantStatement returned error: %v", err)
+	}
+
+	want := types.Statement{
+		RestaurantId:       "restaurant-1",
+		Currency:           "EUR",
+		Period:             types.Period{Year: 2026, Month: time.March},
+		From:               time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+		To:                 time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+		Final:              true,
+		OpeningBalance:     950,
+		OpeningEscrow:      0,
+		GrossPurchases:     1500,
+		Purchases:          2,
+		CommissionDeducted: 40,
+		Refunds:            400,
+		Payouts:            500,
+		Adjustments:        50,
+		ClosingBalance:     1560,
+		ClosingEscrow:      300,
+	}
+	if statement != want {
+		t.Errorf("statement = %+v\nwant        %+v", statement, want)
+	}
+
+	figures := statement.OpeningBalance + statement.GrossPurchases - statement.CommissionDeducted -
+		statement.Refunds - statement.Payouts + statement.Adjustments
+	if figures != statement.ClosingBalance {
+		t.Errorf("figures add up to %d, want the closing balance %d", figures, statement.ClosingBalance)
+	}
+}
@@ -1,7 +1,55 @@
 package types
 
+import "time"
+
 type Balance struct {
-	UserId          string  `bson:"userid"`
-	Amount          float32 `bson:"amount"`
-	TotalCommission float32 `bson:"total_commission"`
-}
\ No newline at end of file
+	UserId          string   `bson:"userid"`
+	Currency        Currency `bson:"currency"`
+	Amount          Money    `bson:"amount"`
+	TotalCommission Money    `bson:"total_commission"`
+	// Escrow holds purchase proceeds that are not released to Amount yet. It
+	// is not available for spending or payouts.
+	Escrow Money `bson:"escrow"`
+	// Reserved is the part of Amount set aside for transactions that passed
+	// the funds check but were not applied yet, and for active holds.
+	Reserved Money `bson:"reserved"`
+	// OverdraftLimit is how far below zero the balance may be spent.
+	OverdraftLimit Money `bson:"overdraft_limit"`
+	// Sequence increases with every change to the wallet's balance or
+	// escrow, and orders the postings applied to it.
+	Sequence int64 `bson:"sequence,omitempty"`
+}
+
+// Available returns the balance that is not reserved for pending transactions
+// or held.
+func (b Balance) Available() Money {
+	return b.Amount - b.Reserved
+}
+
+// Ledger returns the balance booked in the journal. Holds and pending
+// transactions do not change it until they are applied.
+func (b Balance) Ledger() Money {
+	return b.Amount
+}
+
+// BalanceSnapshot is an account's balance in one currency as of a point in
+// time, as computed from its postings. Commission is its total commission:
+// earned for the platform, paid for everyone else.
+type BalanceSnapshot struct {
+	Account    string    `bson:"account"`
+	Currency   Currency  `bson:"currency"`
+	AsOf       time.Time `bson:"as_of"`
+	Amount     Money     `bson:"amount"`
+	Escrow     Money     `bson:"escrow"`
+	Commission Money     `bson:"commission"`
+	Postings   int64     `bson:"postings"`
+}
+
+// Add returns the snapshot moved forward by the postings totalled in delta.
+func (s BalanceSnapshot) Add(delta BalanceSnapshot) BalanceSnapshot {
+	s.Amount += delta.Amount
+	s.Escrow += delta.Escrow
+	s.Commission += delta.Commission
+	s.Postings += delta.Postings
+	return s
+}
@@ -0,0 +1,157 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// CommissionPolicy sets the commission charged on a restaurant's purchases.
+// The policy with an empty RestaurantId is the default for restaurants that
+// have no policy of their own.
+//
+// A purchase is charged by the schedule rule in effect when it was created,
+// and at Rate when no rule is.
+type CommissionPolicy struct {
+	RestaurantId string           `bson:"restaurant_id"`
+	Rate         Rate             `bson:"rate"`
+	Schedule     []CommissionRule `bson:"schedule,omitempty"`
+	UpdatedAt    time.Time        `bson:"updated_at"`
+}
+
+// CommissionRule applies to purchases created in [ValidFrom, ValidTo). The
+// rate is picked from Tiers by the restaurant's gross purchase volume earlier
+// in the same calendar month (UTC), and the resulting fee is kept within
+// [MinFee, MaxFee]; a zero MaxFee means no cap.
+type CommissionRule struct {
+	ValidFrom time.Time  `bson:"valid_from"`
+	ValidTo   *time.Time `bson:"valid_to,omitempty"`
+	// Currency restricts the rule to purchases in that currency. Fee bounds
+	// and tier thresholds are in its minor units.
+	Currency Currency         `bson:"currency,omitempty"`
+	Tiers    []CommissionTier `bson:"tiers"`
+	MinFee   Money            `bson:"min_fee,omitempty"`
+	MaxFee   Money            `bson:"max_fee,omitempty"`
+}
+
+// CommissionTier charges Rate once the monthly volume reaches MinVolume.
+type CommissionTier struct {
+	MinVolume Money `bson:"min_volume"`
+	Rate      Rate  `bson:"rate"`
+}
+
+func (p CommissionPolicy) IsDefault() bool {
+	return p.RestaurantId == ""
+}
+
+// RuleAt returns the schedule rule in effect for a purchase in currency
+// created at t.
+func (p CommissionPolicy) RuleAt(t time.Time, currency Currency) (CommissionRule, bool) {
+	for _, rule := range p.Schedule {
+		if rule.covers(t) && (rule.Currency == "" || rule.Currency == currency) {
+			return rule, true
+		}
+	}
+	return CommissionRule{}, false
+}
+
+// Validate checks rates, tiers and fee bounds, and that no two rules could
+// apply to the same purchase.
+func (p CommissionPolicy) Validate() error {
+	if !p.Rate.valid() {
+		return fmt.Errorf("%w: %d", ErrInvalidCommissionRate, p.Rate)
+	}
+
+	for i, rule := range p.Schedule {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("%w: rule %d: %v", ErrInvalidCommissionSchedule, i, err)
+		}
+		for j := 0; j < i; j++ {
+			if rule.overlaps(p.Schedule[j]) {
+				return fmt.Errorf("%w: rules %d and %d overlap", ErrInvalidCommissionSchedule, j, i)
+			}
+		}
+	}
+	return nil
+}
+
+// Tier returns the tier reached by the given monthly volume.
+func (r CommissionRule) Tier(volume Money) CommissionTier {
+	tier := r.Tiers[0]
+	for _, t := range r.Tiers[1:] {
+		if volume < t.MinVolume {
+			break
+		}
+		tier = t
+	}
+	return tier
+}
+
+// Fee computes the commission on a purchase of amount at rate, bounded by the
+// rule's minimum and maximum fee and never more than the purchase itself.
+func (r CommissionRule) Fee(amount Money, rate Rate, mode RoundingMode) (Money, error) {
+	fee, err := amount.MulRate(rate, mode)
+	if err != nil {
+		return 0, err
+	}
+	if fee < r.MinFee {
+		fee = r.MinFee
+	}
+	if r.MaxFee > 0 && fee > r.MaxFee {
+		fee = r.MaxFee
+	}
+	if fee > amount {
+		fee = amount
+	}
+	return fee, nil
+}
+
+// Tiered reports whether the rate depends on the monthly volume.
+func (r CommissionRule) Tiered() bool {
+	return len(r.Tiers) > 1
+}
+
+func (r CommissionRule) covers(t time.Time) bool {
+	return !t.Before(r.ValidFrom) && (r.ValidTo == nil || t.Before(*r.ValidTo))
+}
+
+func (r CommissionRule) overlaps(other CommissionRule) bool {
+	if r.Currency != "" && other.Currency != "" && r.Currency != other.Currency {
+		return false
+	}
+	startsBeforeOtherEnds := other.ValidTo == nil || r.ValidFrom.Before(*other.ValidTo)
+	otherStartsBeforeEnd := r.ValidTo == nil || other.ValidFrom.Before(*r.ValidTo)
+	return startsBeforeOtherEnds && otherStartsBeforeEnd
+}
+
+func (r CommissionRule) validate() error {
+	if r.ValidTo != nil && !r.ValidTo.After(r.ValidFrom) {
+		return fmt.Errorf("valid_to must be after valid_from")
+	}
+	if r.Currency != "" && !r.Currency.Valid() {
+		return fmt.Errorf("%w: %s", ErrUnsupportedCurrency, r.Currency)
+	}
+	if len(r.Tiers) == 0 {
+		return fmt.Errorf("at least one tier is required")
+	}
+	if r.Tiers[0].MinVolume != 0 {
+		return fmt.Errorf("the first tier must start at a volume of 0")
+	}
+	for i, tier := range r.Tiers {
+		if !tier.Rate.valid() {
+			return fmt.Errorf("%w: %d", ErrInvalidCommissionRate, tier.Rate)
+		}
+		if i > 0 && tier.MinVolume <= r.Tiers[i-1].MinVolume {
+			return fmt.Errorf("tier volumes must be increasing")
+		}
+	}
+	if r.MinFee < 0 || r.MaxFee < 0 {
+		return fmt.Errorf("fees must not be negative")
+	}
+	if r.MaxFee > 0 && r.MaxFee < r.MinFee {
+		return fmt.Errorf("max_fee must not be less than min_fee")
+	}
+	if r.Currency == "" && (r.Tiered() || r.MinFee > 0 || r.MaxFee > 0) {
+		return fmt.Errorf("a currency is required for tier volumes and fee bounds")
+	}
+	return nil
+}
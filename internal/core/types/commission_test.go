@@ -0,0 +1,153 @@
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCommissionRuleTier(t *testing.T) {
+	rule := CommissionRule{
+		Currency: "EUR",
+		Tiers: []CommissionTier{
+			{MinVolume: 0, Rate: 800},
+			{MinVolume: 100000, Rate: 600},
+			{MinVolume: 500000, Rate: 400},
+		},
+	}
+
+	tests := []struct {
+		volume Money
+		want   Rate
+	}{
+		{0, 800},
+		{99999, 800},
+		{100000, 600},
+		{499999, 600},
+		{500000, 400},
+		{10000000, 400},
+	}
+
+	for _, tt := range tests {
+		if got := rule.Tier(tt.volume).Rate; got != tt.want {
+			t.Errorf("Tier(%d).Rate = %d, want %d", tt.volume, got, tt.want)
+		}
+	}
+}
+
+func TestCommissionRuleFee(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   CommissionRule
+		amount Money
+		rate   Rate
+		want   Money
+	}{
+		{"rate only", CommissionRule{}, 10000, 500, 500},
+		{"rounded half even", CommissionRule{}, 1010, 500, 50},
+		{"raised to min fee", CommissionRule{MinFee: 100}, 1000, 500, 100},
+		{"capped at max fee", CommissionRule{MaxFee: 300}, 10000, 500, 300},
+		{"between bounds", CommissionRule{MinFee: 100, MaxFee: 1000}, 10000, 500, 500},
+		{"zero max fee is no cap", CommissionRule{MaxFee: 0}, 1000000, 500, 50000},
+		{"min fee never exceeds the purchase", CommissionRule{MinFee: 100}, 60, 500, 60},
+		{"zero rate still charges min fee", CommissionRule{MinFee: 25}, 1000, 0, 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.rule.Fee(tt.amount, tt.rate, RoundHalfEven)
+			if err != nil {
+				t.Fatalf("Fee returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Fee(%d, %d) = %d, want %d", tt.amount, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommissionPolicyRuleAt(t *testing.T) {
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	policy := CommissionPolicy{
+		Rate: 500,
+		Schedule: []CommissionRule{
+			{ValidFrom: march, ValidTo: &april, Currency: "EUR", Tiers: []CommissionTier{{Rate: 300}}},
+			{ValidFrom: april, Tiers: []CommissionTier{{Rate: 200}}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		currency Currency
+		want     Rate
+		found    bool
+	}{
+		{"before every rule", march.Add(-time.Second), "EUR", 0, false},
+		{"start is inclusive", march, "EUR", 300, true},
+		{"other currency", march, "USD", 0, false},
+		{"end is exclusive", april, "EUR", 200, true},
+		{"open ended rule", april.AddDate(1, 0, 0), "USD", 200, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, found := policy.RuleAt(tt.at, tt.currency)
+			if found != tt.found {
+				t.Fatalf("RuleAt found = %t, want %t", found, tt.found)
+			}
+			if found && rule.Tiers[0].Rate != tt.want {
+				t.Errorf("RuleAt rate = %d, want %d", rule.Tiers[0].Rate, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommissionPolicyValidate(t *testing.T) {
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	tiers := []CommissionTier{{MinVolume: 0, Rate: 500}, {MinVolume: 1000, Rate: 400}}
+
+	tests := []struct {
+		name   string
+		policy CommissionPolicy
+		want   error
+	}{
+		{"flat rate", CommissionPolicy{Rate: 500}, nil},
+		{"rate above 100%", CommissionPolicy{Rate: RateScale + 1}, ErrInvalidCommissionRate},
+		{"negative rate", CommissionPolicy{Rate: -1}, ErrInvalidCommissionRate},
+		{"tiered rule", CommissionPolicy{Schedule: []CommissionRule{{ValidFrom: march, Currency: "EUR", Tiers: tiers}}}, nil},
+		{"tiered rule without currency", CommissionPolicy{Schedule: []CommissionRule{{ValidFrom: march, Tiers: tiers}}}, ErrInvalidCommissionSchedule},
+		{"no tiers", CommissionPolicy{Schedule: []CommissionRule{{ValidFrom: march}}}, ErrInvalidCommissionSchedule},
+		{"first tier above zero", CommissionPolicy{Schedule: []CommissionRule{{ValidFrom: march, Currency: "EUR", Tiers: []CommissionTier{{MinVolume: 1, Rate: 500}}}}}, ErrInvalidCommissionSchedule},
+		{"decreasing tiers", CommissionPolicy{Schedule: []CommissionRule{{ValidFrom: march, Currency: "EUR", Tiers: []CommissionTier{{Rate: 500}, {MinVolume: 1000, Rate: 400}, {MinVolume: 1000, Rate: 300}}}}}, ErrInvalidCommissionSchedule},
+		{"max fee below min fee", CommissionPolicy{Schedule: []CommissionRule{{ValidFrom: march, Currency: "EUR", Tiers: []CommissionTier{{Rate: 500}}, MinFee: 200, MaxFee: 100}}}, ErrInvalidCommissionSchedule},
+		{"negative fee", CommissionPolicy{Schedule: []CommissionRule{{ValidFrom: march, Currency: "EUR", Tiers: []CommissionTier{{Rate: 500}}, MinFee: -1}}}, ErrInvalidCommissionSchedule},
+		{"empty validity", CommissionPolicy{Schedule: []CommissionRule{{ValidFrom: march, ValidTo: &march, Tiers: []CommissionTier{{Rate: 500}}}}}, ErrInvalidCommissionSchedule},
+		{"overlapping rules", CommissionPolicy{Schedule: []CommissionRule{
+			{ValidFrom: march, Tiers: []CommissionTier{{Rate: 500}}},
+			{ValidFrom: april, Tiers: []CommissionTier{{Rate: 400}}},
+		}}, ErrInvalidCommissionSchedule},
+		{"adjacent rules", CommissionPolicy{Schedule: []CommissionRule{
+			{ValidFrom: march, ValidTo: &april, Tiers: []CommissionTier{{Rate: 500}}},
+			{ValidFrom: april, Tiers: []CommissionTier{{Rate: 400}}},
+		}}, nil},
+		{"same period in different currencies", CommissionPolicy{Schedule: []CommissionRule{
+			{ValidFrom: march, Currency: "EUR", Tiers: []CommissionTier{{Rate: 500}}},
+			{ValidFrom: march, Currency: "USD", Tiers: []CommissionTier{{Rate: 400}}},
+		}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.want == nil && err != nil {
+				t.Errorf("Validate() error = %v, want nil", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package types
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+// currencyMinorUnits maps supported currencies to their ISO 4217 minor unit
+// exponent, i.e. the number of decimal places of one major unit.
+var currencyMinorUnits = map[Currency]int{
+	"AED": 2,
+	"AUD": 2,
+	"BHD": 3,
+	"BRL": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"DKK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"INR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"MXN": 2,
+	"NOK": 2,
+	"PLN": 2,
+	"SAR": 2,
+	"SEK": 2,
+	"TRY": 2,
+	"USD": 2,
+}
+
+func (c Currency) Valid() bool {
+	_, ok := currencyMinorUnits[c]
+	return ok
+}
+
+// MinorUnits returns the number of decimal places used by the currency.
+func (c Currency) MinorUnits() int {
+	return currencyMinorUnits[c]
+}
+
+// MinorUnitsPerMajor returns how many minor units make up one major unit.
+func (c Currency) MinorUnitsPerMajor() int64 {
+	factor := int64(1)
+	for i := 0; i < c.MinorUnits(); i++ {
+		factor *= 10
+	}
+	return factor
+}
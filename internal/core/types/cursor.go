@@ -0,0 +1,40 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor marks a position in a listing ordered by time and then by id. It is
+// handed to clients as an opaque string.
+type Cursor struct {
+	At time.Time
+	Id string
+}
+
+func (c Cursor) Encode() string {
+	raw := strconv.FormatInt(c.At.UnixNano(), 10) + "|" + c.Id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a cursor produced by Encode.
+func ParseCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	nanos, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return Cursor{At: time.Unix(0, n).UTC(), Id: id}, nil
+}
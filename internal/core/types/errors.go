@@ -0,0 +1,42 @@
+package types
+
+import "errors"
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrCurrencyMismatch    = errors.New("currency does not match customer wallet")
+	ErrInsufficientFunds   = errors.New("insufficient funds")
+	ErrSelfTransfer        = errors.New("cannot transfer to the same customer")
+	ErrInvalidOrder        = errors.New("invalid order")
+	ErrAmountOverflow      = errors.New("amount out of range")
+	ErrDivisionByZero      = errors.New("division by zero")
+
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrUnbalancedJournal   = errors.New("journal entry does not balance")
+	ErrNoPostings          = errors.New("transaction type books no postings")
+	ErrInvalidCursor       = errors.New("invalid pagination cursor")
+	ErrInvalidFilter       = errors.New("invalid transaction filter")
+	ErrInvalidPeriod       = errors.New("invalid statement period")
+
+	ErrNotRefundable         = errors.New("only posted purchases that were not reversed can be refunded")
+	ErrRefundExceedsPurchase = errors.New("refund exceeds the remaining refundable amount")
+	ErrPayoutNotFound        = errors.New("payout not found")
+	ErrPayoutStatusConflict  = errors.New("payout is not in a status that allows this")
+	ErrHoldNotFound          = errors.New("hold not found")
+	ErrHoldNotActive         = errors.New("hold is not active")
+	ErrCaptureExceedsHold    = errors.New("capture exceeds the held amount")
+	ErrEscrowNotHeld         = errors.New("purchase proceeds are not held in escrow")
+	ErrNotReversible         = errors.New("transaction cannot be reversed")
+	ErrAlreadyReversed       = errors.New("transaction was already reversed")
+
+	ErrReconciliationRunning = errors.New("a reconciliation is already running")
+	ErrLeaseHeld             = errors.New("lease is held by another process")
+
+	ErrCommissionPolicyNotFound  = errors.New("commission policy not found")
+	ErrInvalidCommissionRate     = errors.New("commission rate must be between 0 and 10000 basis points")
+	ErrInvalidCommissionSchedule = errors.New("invalid commission schedule")
+
+	ErrIdempotencyKeyExists     = errors.New("idempotency key already exists")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
@@ -0,0 +1,12 @@
+package types
+
+import "time"
+
+// IdempotencyRecord remembers which transaction was created for a client
+// supplied idempotency key, and a fingerprint of the request that created it.
+type IdempotencyRecord struct {
+	Key           string    `bson:"key"`
+	RequestHash   string    `bson:"request_hash"`
+	TransactionId string    `bson:"transaction_id"`
+	CreatedAt     time.Time `bson:"created_at"`
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Money is a monetary amount stored as an integer number of minor units
+// (e.g. cents), so that ledger arithmetic is exact.
+type Money int64
+
+// Rate is a proportion expressed in basis points (1/100 of a percent).
+type Rate int64
+
+const RateScale = 10000
+
+func (r Rate) valid() bool {
+	return r >= 0 && r <= RateScale
+}
+
+type RoundingMode int
+
+const (
+	RoundHalfEven RoundingMode = iota
+	RoundHalfUp
+	RoundDown // towards zero
+	RoundUp   // away from zero
+)
+
+// Add returns m + o. It fails with ErrAmountOverflow if the sum does not fit
+// in a Money.
+func (m Money) Add(o Money) (Money, error) {
+	sum := m + o
+	if (o > 0 && sum < m) || (o < 0 && sum > m) {
+		return 0, fmt.Errorf("%w: %d + %d", ErrAmountOverflow, m, o)
+	}
+	return sum, nil
+}
+
+// Mul returns m * n. It fails with ErrAmountOverflow if the product does not
+// fit in a Money.
+func (m Money) Mul(n int64) (Money, error) {
+	return m.MulDiv(n, 1, RoundDown)
+}
+
+// MulRate returns m scaled by r, rounded to a whole minor unit.
+func (m Money) MulRate(r Rate, mode RoundingMode) (Money, error) {
+	return m.MulDiv(int64(r), RateScale, mode)
+}
+
+// MulDiv returns m * num / den, rounded to a whole minor unit. It fails with
+// ErrAmountOverflow if the result does not fit in a Money, and with
+// ErrDivisionByZero if den is zero.
+func (m Money) MulDiv(num, den int64, mode RoundingMode) (Money, error) {
+	if den == 0 {
+		return 0, fmt.Errorf("%w: %d * %d / 0", ErrDivisionByZero, m, num)
+	}
+
+	n := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(num))
+	d := big.NewInt(den)
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+
+	if r.Sign() != 0 && roundAway(q, r, d, mode) {
+		if n.Sign() == d.Sign() {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("%w: %d * %d / %d", ErrAmountOverflow, m, num, den)
+	}
+	return Money(q.Int64()), nil
+}
+
+func roundAway(q, r, d *big.Int, mode RoundingMode) bool {
+	switch mode {
+	case RoundDown:
+		return false
+	case RoundUp:
+		return true
+	}
+
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	cmp := twice.Cmp(new(big.Int).Abs(d))
+
+	if mode == RoundHalfUp {
+		return cmp >= 0
+	}
+	return cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+}
+
+// Format renders m as a decimal amount with the precision of currency c.
+func (m Money) Format(c Currency) string {
+	sign := ""
+	// The magnitude of the smallest Money does not fit in an int64.
+	v := uint64(m)
+	if m < 0 {
+		sign = "-"
+		v = -v
+	}
+
+	digits := c.MinorUnits()
+	if digits == 0 {
+		return fmt.Sprintf("%s%d", sign, v)
+	}
+
+	factor := uint64(c.MinorUnitsPerMajor())
+	return fmt.Sprintf("%s%d.%0*d", sign, v/factor, digits, v%factor)
+}
@@ -0,0 +1,133 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMoneyMulDiv(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		num, den int64
+		mode     RoundingMode
+		want     Money
+	}{
+		{"exact", 1000, 3, 4, RoundHalfEven, 750},
+		{"half even rounds down to even", 5, 1, 2, RoundHalfEven, 2},
+		{"half even rounds up to even", 7, 1, 2, RoundHalfEven, 4},
+		{"half even above half", 7, 2, 3, RoundHalfEven, 5},
+		{"half up", 5, 1, 2, RoundHalfUp, 3},
+		{"half up below half", 4, 1, 3, RoundHalfUp, 1},
+		{"down", 9, 1, 10, RoundDown, 0},
+		{"up", 1, 1, 10, RoundUp, 1},
+		{"negative half even", -5, 1, 2, RoundHalfEven, -2},
+		{"negative half up", -5, 1, 2, RoundHalfUp, -3},
+		{"negative down is towards zero", -9, 1, 10, RoundDown, 0},
+		{"negative up is away from zero", -1, 1, 10, RoundUp, -1},
+		{"negative denominator", 5, 1, -2, RoundHalfUp, -3},
+		{"intermediate product overflows int64", math.MaxInt64, 3, 3, RoundHalfEven, math.MaxInt64},
+		{"zero", 0, 7, 3, RoundUp, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.MulDiv(tt.num, tt.den, tt.mode)
+			if err != nil {
+				t.Fatalf("MulDiv(%d, %d, %d) returned error: %v", tt.m, tt.num, tt.den, err)
+			}
+			if got != tt.want {
+				t.Errorf("MulDiv(%d, %d, %d) = %d, want %d", tt.m, tt.num, tt.den, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyMulDivOverflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		num, den int64
+		mode     RoundingMode
+	}{
+		{"above max", math.MaxInt64, 2, 1, RoundHalfEven},
+		{"below min", math.MinInt64, 2, 1, RoundHalfEven},
+		{"min negated", math.MinInt64, -1, 1, RoundDown},
+		{"rounding past max", math.MaxInt64, 2*RateScale + 1, 2 * RateScale, RoundUp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.MulDiv(tt.num, tt.den, tt.mode)
+			if !errors.Is(err, ErrAmountOverflow) {
+				t.Errorf("MulDiv(%d, %d, %d) = %d, %v, want ErrAmountOverflow", tt.m, tt.num, tt.den, got, err)
+			}
+		})
+	}
+}
+
+func TestMoneyMulDivByZero(t *testing.T) {
+	if got, err := Money(100).MulDiv(1, 0, RoundHalfEven); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("MulDiv(100, 1, 0) = %d, %v, want ErrDivisionByZero", got, err)
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	tests := []struct {
+		m        Money
+		currency Currency
+		want     string
+	}{
+		{1234, "EUR", "12.34"},
+		{-5, "EUR", "-0.05"},
+		{0, "EUR", "0.00"},
+		{1234, "JPY", "1234"},
+		{math.MaxInt64, "EUR", "92233720368547758.07"},
+		{math.MinInt64, "EUR", "-92233720368547758.08"},
+		{math.MinInt64, "JPY", "-9223372036854775808"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.Format(tt.currency); got != tt.want {
+			t.Errorf("Format(%d, %s) = %s, want %s", tt.m, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyAdd(t *testing.T) {
+	if got, err := Money(5).Add(-7); err != nil || got != -2 {
+		t.Errorf("Add(5, -7) = %d, %v, want -2", got, err)
+	}
+	for _, tt := range [][2]Money{{math.MaxInt64, 1}, {math.MinInt64, -1}} {
+		if got, err := tt[0].Add(tt[1]); !errors.Is(err, ErrAmountOverflow) {
+			t.Errorf("Add(%d, %d) = %d, %v, want ErrAmountOverflow", tt[0], tt[1], got, err)
+		}
+	}
+}
+
+func TestMoneyMulRate(t *testing.T) {
+	tests := []struct {
+		m    Money
+		rate Rate
+		mode RoundingMode
+		want Money
+	}{
+		{10000, 1500, RoundHalfEven, 1500},
+		{333, 1500, RoundHalfEven, 50},
+		{1, 5000, RoundHalfEven, 0},
+		{3, 5000, RoundHalfEven, 2},
+		{1, 5000, RoundHalfUp, 1},
+		{12345, RateScale, RoundDown, 12345},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.m.MulRate(tt.rate, tt.mode)
+		if err != nil {
+			t.Fatalf("MulRate(%d, %d) returned error: %v", tt.m, tt.rate, err)
+		}
+		if got != tt.want {
+			t.Errorf("MulRate(%d, %d) = %d, want %d", tt.m, tt.rate, got, tt.want)
+		}
+	}
+}
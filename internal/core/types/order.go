@@ -0,0 +1,140 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OrderComponent is a part of what the customer pays for an order.
+type OrderComponent string
+
+const (
+	ITEMS        OrderComponent = "ITEMS"
+	DELIVERY_FEE OrderComponent = "DELIVERY_FEE"
+	SERVICE_FEE  OrderComponent = "SERVICE_FEE"
+	TIP          OrderComponent = "TIP"
+)
+
+func (c OrderComponent) Valid() bool {
+	switch c {
+	case ITEMS, DELIVERY_FEE, SERVICE_FEE, TIP:
+		return true
+	}
+	return false
+}
+
+// ParseOrderComponents parses a comma separated list of components, e.g.
+// "ITEMS,DELIVERY_FEE".
+func ParseOrderComponents(s string) ([]OrderComponent, error) {
+	components := []OrderComponent{}
+	for _, part := range strings.Split(s, ",") {
+		component := OrderComponent(strings.TrimSpace(part))
+		if component == "" {
+			continue
+		}
+		if !component.Valid() {
+			return nil, fmt.Errorf("unknown order component %q", component)
+		}
+		components = append(components, component)
+	}
+	return components, nil
+}
+
+// Order breaks a purchase down into what the customer pays for.
+type Order struct {
+	Items       []LineItem `bson:"items"`
+	DeliveryFee Money      `bson:"delivery_fee,omitempty"`
+	ServiceFee  Money      `bson:"service_fee,omitempty"`
+	Tip         Money      `bson:"tip,omitempty"`
+}
+
+type LineItem struct {
+	Name      string `bson:"name"`
+	Quantity  int64  `bson:"quantity"`
+	UnitPrice Money  `bson:"unit_price"`
+}
+
+// Total is the price of the line item. It fails with ErrAmountOverflow if
+// it does not fit in a Money, as do the totals of the order below.
+func (i LineItem) Total() (Money, error) {
+	return i.UnitPrice.Mul(i.Quantity)
+}
+
+// Subtotal totals the line items.
+func (o Order) Subtotal() (Money, error) {
+	var subtotal Money
+	for _, item := range o.Items {
+		total, err := item.Total()
+		if err != nil {
+			return 0, err
+		}
+		if subtotal, err = subtotal.Add(total); err != nil {
+			return 0, err
+		}
+	}
+	return subtotal, nil
+}
+
+// Total is what the customer pays for the order.
+func (o Order) Total() (Money, error) {
+	total, err := o.Subtotal()
+	if err != nil {
+		return 0, err
+	}
+	for _, fee := range []Money{o.DeliveryFee, o.ServiceFee, o.Tip} {
+		if total, err = total.Add(fee); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// Components returns the amount of each component, in a fixed order.
+func (o Order) Components() ([]OrderAmount, error) {
+	subtotal, err := o.Subtotal()
+	if err != nil {
+		return nil, err
+	}
+	return []OrderAmount{
+		{ITEMS, subtotal},
+		{DELIVERY_FEE, o.DeliveryFee},
+		{SERVICE_FEE, o.ServiceFee},
+		{TIP, o.Tip},
+	}, nil
+}
+
+func (o Order) Validate() error {
+	for i, item := range o.Items {
+		if item.Quantity < 1 {
+			return fmt.Errorf("%w: item %d: quantity must be at least 1", ErrInvalidOrder, i)
+		}
+		if item.UnitPrice < 0 {
+			return fmt.Errorf("%w: item %d: unit price must not be negative", ErrInvalidOrder, i)
+		}
+	}
+	if o.DeliveryFee < 0 || o.ServiceFee < 0 || o.Tip < 0 {
+		return fmt.Errorf("%w: fees and tip must not be negative", ErrInvalidOrder)
+	}
+	total, err := o.Total()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidOrder, err)
+	}
+	if total <= 0 {
+		return fmt.Errorf("%w: the order total must be positive", ErrInvalidOrder)
+	}
+	return nil
+}
+
+type OrderAmount struct {
+	Component OrderComponent
+	Amount    Money
+}
+
+// Leg is the part of a purchase credited to one party. Commission is charged
+// on the legs marked Commissionable when the purchase is created.
+type Leg struct {
+	Component      OrderComponent `bson:"component"`
+	Payee          User           `bson:"payee"`
+	Amount         Money          `bson:"amount"`
+	Commissionable bool           `bson:"commissionable,omitempty"`
+}
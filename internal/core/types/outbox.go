@@ -0,0 +1,22 @@
+package types
+
+import "time"
+
+// OutboxEntry records a transaction whose balance effects still have to be
+// applied. It is written atomically with the transaction itself.
+type OutboxEntry struct {
+	TransactionId string      `bson:"transaction_id"`
+	Transaction   Transaction `bson:"transaction"`
+	CreatedAt     time.Time   `bson:"created_at"`
+	DispatchedAt  *time.Time  `bson:"dispatched_at"`
+	AppliedAt     *time.Time  `bson:"applied_at"`
+	// DispatchedBy names the service instance the entry was last dispatched
+	// to.
+	DispatchedBy string `bson:"dispatched_by,omitempty"`
+	// Failures counts the dispatches that did not apply the transaction, and
+	// LastError records why the latest one did not. An entry that keeps
+	// failing is dead-lettered and not dispatched again.
+	Failures       int        `bson:"failures,omitempty"`
+	LastError      string     `bson:"last_error,omitempty"`
+	DeadLetteredAt *time.Time `bson:"dead_lettered_at,omitempty"`
+}
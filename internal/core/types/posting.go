@@ -0,0 +1,45 @@
+package types
+
+import "time"
+
+type PostingKind string
+
+const (
+	PRINCIPAL_POSTING  PostingKind = "PRINCIPAL"
+	COMMISSION_POSTING PostingKind = "COMMISSION"
+)
+
+// Posting is one line of a transaction's journal entry. Amount is signed:
+// credits (positive) increase the account balance and debits (negative)
+// decrease it. The postings of a transaction sum to zero in every currency.
+type Posting struct {
+	Id            string      `bson:"id"`
+	TransactionId string      `bson:"transaction_id"`
+	Account       string      `bson:"account"`
+	Currency      Currency    `bson:"currency"`
+	Amount        Money       `bson:"amount"`
+	Kind          PostingKind `bson:"kind"`
+	// Escrow postings change the account's escrow instead of its balance.
+	Escrow bool `bson:"escrow,omitempty"`
+	// Platform marks the platform's side of a commission posting. Commission
+	// postings of the platform add to the commission it earned, those of
+	// everyone else to the commission they paid.
+	Platform bool `bson:"platform,omitempty"`
+	// BalanceAfter and EscrowAfter are the account's balance and escrow in
+	// the currency right after the posting was applied. Postings backfilled
+	// for user accounts do not have them.
+	BalanceAfter *Money `bson:"balance_after,omitempty"`
+	EscrowAfter  *Money `bson:"escrow_after,omitempty"`
+	// Sequence is the posting's place among the postings of its account in
+	// the currency, in the order they were applied. Postings booked before it
+	// was recorded do not have it.
+	Sequence int64     `bson:"sequence,omitempty"`
+	PostedAt time.Time `bson:"posted_at"`
+}
+
+// AccountTotal is the sum of an account's postings in one currency.
+type AccountTotal struct {
+	Currency Currency `bson:"_id"`
+	Amount   Money    `bson:"amount"`
+	Postings int64    `bson:"postings"`
+}
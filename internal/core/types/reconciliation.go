@@ -0,0 +1,40 @@
+package types
+
+import "time"
+
+// BalanceDrift is a wallet whose stored figures differ from the ones
+// computed from its postings.
+type BalanceDrift struct {
+	Account            string
+	Currency           Currency
+	StoredAmount       Money
+	ExpectedAmount     Money
+	StoredEscrow       Money
+	ExpectedEscrow     Money
+	StoredCommission   Money
+	ExpectedCommission Money
+	// Repaired is set once the stored figures were corrected.
+	Repaired bool
+}
+
+// ReconciliationReport is the outcome of a reconciliation run.
+type ReconciliationReport struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Repair     bool
+	// Wallets is the number of wallets checked.
+	Wallets int
+	Drifts  []BalanceDrift
+}
+
+// RebuildReport is the outcome of rebuilding the balances from the journal.
+type RebuildReport struct {
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	Transactions int
+	Postings     int
+	// Wallets is the number of wallets rebuilt, and Changed those whose
+	// rebuilt figures differ from the live ones.
+	Wallets int
+	Changed []BalanceDrift
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is a calendar month in UTC.
+type Period struct {
+	Year  int
+	Month time.Month
+}
+
+// ParsePeriod parses a period written as YYYY-MM.
+func ParsePeriod(s string) (Period, error) {
+	t, err := time.Parse("2006-01", s)
+	if err != nil {
+		return Period{}, fmt.Errorf("%w: %q is not a YYYY-MM month", ErrInvalidPeriod, s)
+	}
+	return Period{Year: t.Year(), Month: t.Month()}, nil
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf("%04d-%02d", p.Year, int(p.Month))
+}
+
+// Start returns the first instant of the period.
+func (p Period) Start() time.Time {
+	return time.Date(p.Year, p.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// End returns the first instant after the period.
+func (p Period) End() time.Time {
+	return p.Start().AddDate(0, 1, 0)
+}
+
+// Statement summarises a restaurant's account in one currency over a period.
+// Balances include the proceeds held in escrow, and the figures reconcile:
+// ClosingBalance is OpeningBalance plus GrossPurchases, minus
+// CommissionDeducted, Refunds and Payouts, plus Adjustments.
+type Statement struct {
+	RestaurantId string
+	Currency     Currency
+	Period       Period
+	// From and To bound the postings covered, [From, To). While the period is
+	// still running To is the time the statement was generated and Final is
+	// false.
+	From  time.Time
+	To    time.Time
+	Final bool
+
+	OpeningBalance Money
+	OpeningEscrow  Money
+	GrossPurchases Money
+	Purchases      int64
+	// CommissionDeducted is net of commission refunded in the period.
+	CommissionDeducted Money
+	Refunds            Money
+	Payouts            Money
+	// Adjustments are reversals and any other movement of the account.
+	Adjustments    Money
+	ClosingBalance Money
+	ClosingEscrow  Money
+}
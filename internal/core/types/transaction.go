@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type TransactionType string
 
@@ -8,14 +11,182 @@ const (
 	DEPOSIT    TransactionType = "DEPOSIT"
 	PURCHASE   TransactionType = "PURCHASE"
 	COMMISSION TransactionType = "COMMISSION"
+	// REFUND gives part or all of a purchase back to the customer.
+	REFUND TransactionType = "REFUND"
+	// COMMISSION_REFUND gives the restaurant back the commission charged on
+	// the refunded part of a purchase.
+	COMMISSION_REFUND TransactionType = "COMMISSION_REFUND"
+	// REVERSAL voids a posted transaction with the exact inverse of its
+	// postings.
+	REVERSAL TransactionType = "REVERSAL"
+	// PAYOUT pays restaurant balance out of the ledger.
+	PAYOUT TransactionType = "PAYOUT"
+	// TRANSFER moves balance from one customer to another.
+	TRANSFER TransactionType = "TRANSFER"
+	// HOLD sets funds aside on a customer's balance for an order that may
+	// later be captured as a PURCHASE. It has no postings.
+	HOLD TransactionType = "HOLD"
+	// ESCROW_RELEASE moves a purchase's proceeds from the restaurant's escrow
+	// to its balance.
+	ESCROW_RELEASE TransactionType = "ESCROW_RELEASE"
+)
+
+func (t TransactionType) Valid() bool {
+	switch t {
+	case DEPOSIT, PURCHASE, COMMISSION, REFUND, COMMISSION_REFUND, REVERSAL, PAYOUT, TRANSFER, HOLD, ESCROW_RELEASE:
+		return true
+	}
+	return false
+}
+
+type TransactionStatus string
+
+const (
+	// PENDING transactions were accepted but their balance effects were not
+	// applied yet.
+	PENDING TransactionStatus = "PENDING"
+	POSTED  TransactionStatus = "POSTED"
+	FAILED  TransactionStatus = "FAILED"
+)
+
+// PayoutStatus tracks a PAYOUT through its workflow. It stays PENDING until it
+// is SETTLED and applied, or FAILED when REJECTED.
+type PayoutStatus string
+
+const (
+	PAYOUT_REQUESTED PayoutStatus = "REQUESTED"
+	PAYOUT_APPROVED  PayoutStatus = "APPROVED"
+	PAYOUT_SETTLED   PayoutStatus = "SETTLED"
+	PAYOUT_REJECTED  PayoutStatus = "REJECTED"
+)
+
+// HoldStatus tracks a HOLD. A hold is POSTED once placed; it stays ACTIVE
+// until it is CAPTURED, RELEASED or EXPIRED.
+type HoldStatus string
+
+const (
+	HOLD_ACTIVE   HoldStatus = "ACTIVE"
+	HOLD_CAPTURED HoldStatus = "CAPTURED"
+	HOLD_RELEASED HoldStatus = "RELEASED"
+	HOLD_EXPIRED  HoldStatus = "EXPIRED"
+)
+
+// EscrowStatus tracks the proceeds of a purchase held in the restaurant's
+// escrow.
+type EscrowStatus string
+
+const (
+	ESCROW_HELD     EscrowStatus = "HELD"
+	ESCROW_RELEASED EscrowStatus = "RELEASED"
 )
 
 type Transaction struct {
-	Id                 string          `bson:"id"`
-	Type               TransactionType `bson:"type"`
-	Amount             float32         `bson:"amount"`
-	Customer           User            `bson:"customer"`
-	Restaurant         User            `bson:"restaurant"`
-	CreatedAt          time.Time       `bson:"created_at"`
-	RelatedTransaction string          `bson:"related_transaction"`
+	Id            string            `bson:"id"`
+	Type          TransactionType   `bson:"type"`
+	Status        TransactionStatus `bson:"status"`
+	FailureReason string            `bson:"failure_reason,omitempty"`
+	Amount        Money             `bson:"amount"`
+	Currency      Currency          `bson:"currency"`
+	Customer      User              `bson:"customer"`
+	Restaurant    User              `bson:"restaurant"`
+	// Recipient is the customer credited by a TRANSFER.
+	Recipient *User `bson:"recipient,omitempty"`
+	// Platform is set on transactions that credit the platform account.
+	Platform *User `bson:"platform,omitempty"`
+	// Courier delivers the order of a purchase and is paid its delivery fee
+	// and tip.
+	Courier *User `bson:"courier,omitempty"`
+	// Order is the breakdown of a purchase, and Legs record which party each
+	// of its components is credited to. Purchases without legs credit their
+	// whole amount to the restaurant. The legs of a refund are the part of
+	// each leg of the purchase it gives back.
+	Order              *Order    `bson:"order,omitempty"`
+	Legs               []Leg     `bson:"legs,omitempty"`
+	CreatedAt          time.Time `bson:"created_at"`
+	RelatedTransaction string    `bson:"related_transaction"`
+	// CommissionRate is the rate a COMMISSION transaction was computed with.
+	CommissionRate Rate `bson:"commission_rate,omitempty"`
+	// ReservedAmount is the amount reserved on the customer's balance (the
+	// restaurant's for refunds and payouts) when the transaction was
+	// accepted, released once it is applied.
+	ReservedAmount Money `bson:"reserved_amount,omitempty"`
+	// RefundedAmount is the part of a purchase claimed by refunds that were
+	// accepted, whether they are posted yet or not.
+	RefundedAmount Money `bson:"refunded_amount,omitempty"`
+	// Reversed is set once a reversal of the transaction was accepted.
+	Reversed     bool         `bson:"reversed,omitempty"`
+	PayoutStatus PayoutStatus `bson:"payout_status,omitempty"`
+	HoldStatus   HoldStatus   `bson:"hold_status,omitempty"`
+	// ExpiresAt is when an ACTIVE hold is released automatically.
+	ExpiresAt *time.Time `bson:"expires_at,omitempty"`
+	// Escrowed is set on purchases whose proceeds were credited to the
+	// restaurant's escrow, and on refunds and reversals that were booked
+	// against those proceeds while they were held. EscrowAmount is what is
+	// left of them there until they are released, on delivery or at
+	// EscrowReleaseAt.
+	Escrowed        bool         `bson:"escrowed,omitempty"`
+	EscrowStatus    EscrowStatus `bson:"escrow_status,omitempty"`
+	EscrowAmount    Money        `bson:"escrow_amount,omitempty"`
+	EscrowReleaseAt *time.Time   `bson:"escrow_release_at,omitempty"`
+	DeliveredAt     *time.Time   `bson:"delivered_at,omitempty"`
+	// Reason and Operator record why and by whom a reversal was made.
+	Reason   string     `bson:"reason,omitempty"`
+	Operator string     `bson:"operator,omitempty"`
+	PostedAt *time.Time `bson:"posted_at,omitempty"`
+}
+
+// RestaurantShare is the part of a purchase credited to the restaurant.
+func (t Transaction) RestaurantShare() Money {
+	if len(t.Legs) == 0 {
+		return t.Amount
+	}
+
+	var share Money
+	for _, leg := range t.Legs {
+		if leg.Payee.Id == t.Restaurant.Id {
+			share += leg.Amount
+		}
+	}
+	return share
+}
+
+// CommissionBase is the part of a purchase commission is charged on.
+func (t Transaction) CommissionBase() Money {
+	if len(t.Legs) == 0 {
+		return t.Amount
+	}
+
+	var base Money
+	for _, leg := range t.Legs {
+		if leg.Commissionable {
+			base += leg.Amount
+		}
+	}
+	return base
+}
+
+// TransactionFilter narrows a transaction listing. Zero fields do not filter.
+type TransactionFilter struct {
+	Types     []TransactionType
+	MinAmount Money
+	MaxAmount Money
+	// From and To bound the creation time to [From, To).
+	From time.Time
+	To   time.Time
+}
+
+// Validate rejects unknown types and ranges that cannot match anything.
+func (f TransactionFilter) Validate() error {
+	for _, t := range f.Types {
+		if !t.Valid() {
+			return fmt.Errorf("%w: unknown transaction type %q", ErrInvalidFilter, t)
+		}
+	}
+	if f.MaxAmount != 0 && f.MinAmount > f.MaxAmount {
+		return fmt.Errorf("%w: minAmount %d is above maxAmount %d", ErrInvalidFilter, f.MinAmount, f.MaxAmount)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidFilter)
+	}
+	return nil
 }
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTransactionFilterValidate(t *testing.T) {
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  TransactionFilter
+		wantErr bool
+	}{
+		{"empty", TransactionFilter{}, false},
+		{"known types", TransactionFilter{Types: []TransactionType{PURCHASE, REFUND}}, false},
+		{"unknown type", TransactionFilter{Types: []TransactionType{PURCHASE, "purchase"}}, true},
+		{"amount range", TransactionFilter{MinAmount: 100, MaxAmount: 100}, false},
+		{"min above max", TransactionFilter{MinAmount: 101, MaxAmount: 100}, true},
+		{"min without max", TransactionFilter{MinAmount: 101}, false},
+		{"time range", TransactionFilter{From: march, To: april}, false},
+		{"empty time range", TransactionFilter{From: april, To: april}, true},
+		{"inverted time range", TransactionFilter{From: april, To: march}, true},
+		{"open time range", TransactionFilter{From: april}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantErr && !errors.Is(err, ErrInvalidFilter) {
+				t.Errorf("Validate() error = %v, want ErrInvalidFilter", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Validate() error = %v, want nil", err)
+			}
+		})
+	}
+}
@@ -5,9 +5,13 @@ type UserType string
 const (
 	CUSTOMER   UserType = "CUSTOMER"
 	RESTAURANT UserType = "RESTAURANT"
+	// PLATFORM is the house account that earns commission.
+	PLATFORM UserType = "PLATFORM"
+	// COURIER delivers orders and is paid delivery fees and tips.
+	COURIER UserType = "COURIER"
 )
 
 type User struct {
 	Id   string   `bson:"id"`
 	Type UserType `bson:"type"`
-}
\ No newline at end of file
+}
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"ledger-service/internal/core/types"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -9,45 +10,239 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// walletIndex makes a user's wallet unique per currency.
+var walletIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "userid", Value: 1}, {Key: "currency", Value: 1}},
+	Options: options.Index().SetUnique(true),
+}
+
 type BalanceRepository struct {
+	unitOfWork
 	collection *mongo.Collection
 }
 
 func NewBalanceRepository(client *mongo.Client, dbName, collectionName string) *BalanceRepository {
 	collection := client.Database(dbName).Collection(collectionName)
 	return &BalanceRepository{
+		unitOfWork: unitOfWork{client: client},
 		collection: collection,
 	}
 }
 
-func (r *BalanceRepository) GetBalance(ctx context.Context, userId string) (types.Balance, error) {
+func (r *BalanceRepository) GetBalance(ctx context.Context, userId string, currency types.Currency) (types.Balance, error) {
 	var balance types.Balance
-	err := r.collection.FindOne(ctx, bson.M{"userid": userId}).Decode(&balance)
+	err := r.collection.FindOne(ctx, bson.M{"userid": userId, "currency": currency}).Decode(&balance)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return types.Balance{UserId: userId, Amount: 0, TotalCommission: 0}, nil
+			return types.Balance{UserId: userId, Currency: currency, Amount: 0, TotalCommission: 0}, nil
 		}
 		return types.Balance{}, err
 	}
 	return balance, nil
 }
 
-func (r *BalanceRepository) UpdateBalance(ctx context.Context, userId string, amount float32) error {
+func (r *BalanceRepository) GetBalances(ctx context.Context, userId string) ([]types.Balance, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "currency", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"userid": userId}, opts)
+	if err != nil {
+		return []types.Balance{}, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []types.Balance{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return []types.Balance{}, err
+	}
+	return results, nil
+}
+
+func (r *BalanceRepository) ForEach(ctx context.Context, fn func(types.Balance) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "userid", Value: 1}, {Key: "currency", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var balance types.Balance
+		if err := cursor.Decode(&balance); err != nil {
+			return err
+		}
+		if err := fn(balance); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (r *BalanceRepository) UpdateBalance(ctx context.Context, userId string, currency types.Currency, amount types.Money) (types.Balance, error) {
+	return r.increment(ctx, userId, currency, "amount", amount)
+}
+
+func (r *BalanceRepository) UpdateEscrow(ctx context.Context, userId string, currency types.Currency, amount types.Money) (types.Balance, error) {
+	return r.increment(ctx, userId, currency, "escrow", amount)
+}
+
+func (r *BalanceRepository) increment(ctx context.Context, userId string, currency types.Currency, field string, amount types.Money) (types.Balance, error) {
+	filter := bson.M{"userid": userId, "currency": currency}
+	update := bson.M{"$inc": bson.M{field: amount, "sequence": 1}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var balance types.Balance
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&balance); err != nil {
+		return types.Balance{}, err
+	}
+	return balance, nil
+}
 
-	filter := bson.M{"userid": userId}
-	update := bson.M{"$inc": bson.M{"amount": amount}}
+func (r *BalanceRepository) UpdateTotalCommission(ctx context.Context, userId string, currency types.Currency, amount types.Money) error {
+	filter := bson.M{"userid": userId, "currency": currency}
+	update := bson.M{"$inc": bson.M{"total_commission": amount}}
 	opts := options.Update().SetUpsert(true)
 
 	_, err := r.collection.UpdateOne(ctx, filter, update, opts)
 	return err
 }
 
-func (r *BalanceRepository) UpdateTotalCommission(ctx context.Context, userId string, amount float32) error {
-	filter := bson.M{"userid": userId}
-	update := bson.M{"$inc": bson.M{"total_commission": amount}}
+func (r *BalanceRepository) Reserve(ctx context.Context, userId string, currency types.Currency, amount types.Money) error {
+	spendable := bson.M{"$subtract": bson.A{
+		bson.M{"$add": bson.A{"$amount", bson.M{"$ifNull": bson.A{"$overdraft_limit", 0}}}},
+		bson.M{"$ifNull": bson.A{"$reserved", 0}},
+	}}
+	return r.reserve(ctx, userId, currency, amount, spendable)
+}
+
+func (r *BalanceRepository) ReserveAvailable(ctx context.Context, userId string, currency types.Currency, amount types.Money) error {
+	available := bson.M{"$subtract": bson.A{"$amount", bson.M{"$ifNull": bson.A{"$reserved", 0}}}}
+	return r.reserve(ctx, userId, currency, amount, available)
+}
+
+// reserve adds amount to the reserved funds if the spendable expression
+// covers it.
+func (r *BalanceRepository) reserve(ctx context.Context, userId string, currency types.Currency, amount types.Money, spendable bson.M) error {
+	filter := bson.M{
+		"userid":   userId,
+		"currency": currency,
+		"$expr":    bson.M{"$gte": bson.A{spendable, amount}},
+	}
+	update := bson.M{"$inc": bson.M{"reserved": amount}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return types.ErrInsufficientFunds
+	}
+	return nil
+}
+
+func (r *BalanceRepository) UpdateReserved(ctx context.Context, userId string, currency types.Currency, amount types.Money) error {
+	filter := bson.M{"userid": userId, "currency": currency}
+	update := bson.M{"$inc": bson.M{"reserved": amount}}
 	opts := options.Update().SetUpsert(true)
 
 	_, err := r.collection.UpdateOne(ctx, filter, update, opts)
 	return err
 }
 
+func (r *BalanceRepository) SetOverdraftLimit(ctx context.Context, userId string, currency types.Currency, limit types.Money) (types.Balance, error) {
+	filter := bson.M{"userid": userId, "currency": currency}
+	update := bson.M{"$set": bson.M{"overdraft_limit": limit}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var balance types.Balance
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&balance); err != nil {
+		return types.Balance{}, err
+	}
+	return balance, nil
+}
+
+func (r *BalanceRepository) MigrateFloatAmounts(ctx context.Context, currency types.Currency) error {
+	return migrateFloatAmounts(ctx, r.collection, currency, "amount", "total_commission")
+}
+
+// MigrateCurrency assigns the given currency to balances created before
+// multi-currency support and replaces the per-user unique index with a
+// per-(user, currency) one.
+func (r *BalanceRepository) MigrateCurrency(ctx context.Context, currency types.Currency) error {
+	if err := backfillCurrency(ctx, r.collection, currency); err != nil {
+		return err
+	}
+
+	if _, err := r.collection.Indexes().DropOne(ctx, "userid_1"); err != nil && !isIndexNotFound(err) {
+		return err
+	}
+
+	_, err := r.collection.Indexes().CreateOne(ctx, walletIndex)
+	return err
+}
+
+// Shadow returns a repository over an empty collection named after this one
+// with a _rebuild suffix, for the balances to be rebuilt in. Whatever an
+// earlier rebuild left there is dropped.
+func (r *BalanceRepository) Shadow(ctx context.Context) (*BalanceRepository, error) {
+	shadow := &BalanceRepository{
+		unitOfWork: r.unitOfWork,
+		collection: r.collection.Database().Collection(r.collection.Name() + "_rebuild"),
+	}
+
+	if err := shadow.collection.Drop(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := shadow.collection.Indexes().CreateOne(ctx, walletIndex); err != nil {
+		return nil, err
+	}
+	return shadow, nil
+}
+
+// Replace swaps shadow in for this collection, keeping the balances it
+// replaces as a backup; see replaceCollection.
+func (r *BalanceRepository) Replace(ctx context.Context, shadow *BalanceRepository) error {
+	return replaceCollection(ctx, r.collection, shadow.collection)
+}
+
+// replaceCollection swaps shadow in for live with a single rename, which
+// readers observe atomically. The documents it replaces are first copied to a
+// collection named after live with a _backup suffix, overwriting any earlier
+// backup.
+func replaceCollection(ctx context.Context, live, shadow *mongo.Collection) error {
+	cursor, err := live.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$out", Value: live.Name() + "_backup"}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := cursor.Close(ctx); err != nil {
+		return err
+	}
+
+	return renameCollection(ctx, shadow, live.Name())
+}
+
+// restoreCollection puts back the backup replaceCollection made of live.
+func restoreCollection(ctx context.Context, live *mongo.Collection) error {
+	return renameCollection(ctx, live.Database().Collection(live.Name()+"_backup"), live.Name())
+}
+
+// renameCollection renames from to name atomically, replacing any collection
+// of that name.
+func renameCollection(ctx context.Context, from *mongo.Collection, name string) error {
+	db := from.Database()
+	rename := bson.D{
+		{Key: "renameCollection", Value: db.Name() + "." + from.Name()},
+		{Key: "to", Value: db.Name() + "." + name},
+		{Key: "dropTarget", Value: true},
+	}
+	return db.Client().Database("admin").RunCommand(ctx, rename).Err()
+}
+
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		// IndexNotFound, NamespaceNotFound
+		return cmdErr.Code == 27 || cmdErr.Code == 26
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package mongo
+
+import (
+	"context"
+	"ledger-service/internal/core/types"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// snapshotIndex allows one snapshot per account and currency at a time, so a
+// batch of snapshots saved again replaces itself.
+var snapshotIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "account", Value: 1}, {Key: "currency", Value: 1}, {Key: "as_of", Value: 1}},
+	Options: options.Index().SetUnique(true),
+}
+
+// BalanceSnapshotRepository keeps the snapshots in one collection and the
+// time of every completed snapshot run in a second one, named after the
+// first with a "_runs" suffix.
+type BalanceSnapshotRepository struct {
+	collection *mongo.Collection
+	runs       *mongo.Collection
+}
+
+func NewBalanceSnapshotRepository(client *mongo.Client, dbName, collectionName string) *BalanceSnapshotRepository {
+	db := client.Database(dbName)
+	return &BalanceSnapshotRepository{
+		collection: db.Collection(collectionName),
+		runs:       db.Collection(collectionName + "_runs"),
+	}
+}
+
+func (r *BalanceSnapshotRepository) SaveMany(ctx context.Context, snapshots []types.BalanceSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(snapshots))
+	for _, s := range snapshots {
+		filter := bson.M{"account": s.Account, "currency": s.Currency, "as_of": s.AsOf}
+		models = append(models, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(s).SetUpsert(true))
+	}
+
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *BalanceSnapshotRepository) MarkTaken(ctx context.Context, asOf time.Time) error {
+	filter := bson.M{"as_of": asOf}
+	update := bson.M{"$set": bson.M{"as_of": asOf, "completed_at": time.Now()}}
+	_, err := r.runs.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *BalanceSnapshotRepository) Latest(ctx context.Context, account string, currency types.Currency, asOf time.Time) (types.BalanceSnapshot, bool, error) {
+	filter := bson.M{"account": account, "currency": currency, "as_of": bson.M{"$lte": asOf}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "as_of", Value: -1}})
+
+	var snapshot types.BalanceSnapshot
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&snapshot)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return types.BalanceSnapshot{}, false, nil
+		}
+		return types.BalanceSnapshot{}, false, err
+	}
+	return snapshot, true, nil
+}
+
+// LastAsOf reads the latest completed run. Snapshots taken before runs were
+// recorded were saved all at once, so without any run the latest snapshot
+// marks the last complete one.
+func (r *BalanceSnapshotRepository) LastAsOf(ctx context.Context) (time.Time, bool, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "as_of", Value: -1}})
+
+	var run struct {
+		AsOf time.Time `bson:"as_of"`
+	}
+	err := r.runs.FindOne(ctx, bson.M{}, opts).Decode(&run)
+	if err == mongo.ErrNoDocuments {
+		err = r.collection.FindOne(ctx, bson.M{}, opts).Decode(&run)
+	}
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, err
+	}
+	return run.AsOf, true, nil
+}
+
+// DeleteAll removes every snapshot and run. Snapshots only speed up queries
+// and are taken again from the journal.
+func (r *BalanceSnapshotRepository) DeleteAll(ctx context.Context) error {
+	if _, err := r.runs.DeleteMany(ctx, bson.M{}); err != nil {
+		return err
+	}
+	_, err := r.collection.DeleteMany(ctx, bson.M{})
+	return err
+}
+
+// EnsureIndexes replaces the per-account index of earlier versions with the
+// unique snapshotIndex.
+func (r *BalanceSnapshotRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := r.collection.Indexes().DropOne(ctx, "account_1_currency_1_as_of_-1"); err != nil && !isIndexNotFound(err) {
+		return err
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		snapshotIndex,
+		{Keys: bson.D{{Key: "as_of", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.runs.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "as_of", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,77 @@
+package mongo
+
+import (
+	"context"
+	"ledger-service/internal/core/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommissionPolicyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCommissionPolicyRepository(client *mongo.Client, dbName, collectionName string) *CommissionPolicyRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &CommissionPolicyRepository{
+		collection: collection,
+	}
+}
+
+func (r *CommissionPolicyRepository) Get(ctx context.Context, restaurantId string) (types.CommissionPolicy, bool, error) {
+	var policy types.CommissionPolicy
+	err := r.collection.FindOne(ctx, bson.M{"restaurant_id": restaurantId}).Decode(&policy)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return types.CommissionPolicy{}, false, nil
+		}
+		return types.CommissionPolicy{}, false, err
+	}
+	return policy, true, nil
+}
+
+func (r *CommissionPolicyRepository) GetAll(ctx context.Context) ([]types.CommissionPolicy, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "restaurant_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return []types.CommissionPolicy{}, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []types.CommissionPolicy{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return []types.CommissionPolicy{}, err
+	}
+	return results, nil
+}
+
+func (r *CommissionPolicyRepository) Upsert(ctx context.Context, policy types.CommissionPolicy) (types.CommissionPolicy, error) {
+	filter := bson.M{"restaurant_id": policy.RestaurantId}
+	opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved types.CommissionPolicy
+	if err := r.collection.FindOneAndReplace(ctx, filter, policy, opts).Decode(&saved); err != nil {
+		return types.CommissionPolicy{}, err
+	}
+	return saved, nil
+}
+
+func (r *CommissionPolicyRepository) Delete(ctx context.Context, restaurantId string) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"restaurant_id": restaurantId})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// EnsureIndexes creates the unique index that keeps a single policy per
+// restaurant.
+func (r *CommissionPolicyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "restaurant_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"context"
+	"ledger-service/internal/core/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdempotencyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewIdempotencyRepository(client *mongo.Client, dbName, collectionName string) *IdempotencyRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &IdempotencyRepository{
+		collection: collection,
+	}
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, key string) (types.IdempotencyRecord, bool, error) {
+	var record types.IdempotencyRecord
+	err := r.collection.FindOne(ctx, bson.M{"key": key}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return types.IdempotencyRecord{}, false, nil
+		}
+		return types.IdempotencyRecord{}, false, err
+	}
+	return record, true, nil
+}
+
+func (r *IdempotencyRepository) Create(ctx context.Context, record types.IdempotencyRecord) error {
+	_, err := r.collection.InsertOne(ctx, record)
+	if mongo.IsDuplicateKeyError(err) {
+		return types.ErrIdempotencyKeyExists
+	}
+	return err
+}
+
+// EnsureIndexes creates the unique index on key that Create relies on to
+// reject concurrent reuse of the same key.
+func (r *IdempotencyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,79 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LeaseRepository struct {
+	collection *mongo.Collection
+}
+
+type leaseDocument struct {
+	Name      string    `bson:"name"`
+	Holder    string    `bson:"holder"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+func NewLeaseRepository(client *mongo.Client, dbName, collectionName string) *LeaseRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &LeaseRepository{
+		collection: collection,
+	}
+}
+
+func (r *LeaseRepository) Renew(ctx context.Context, name, holder string, ttl time.Duration) error {
+	filter := bson.M{"name": name, "holder": holder}
+	update := bson.M{"$set": bson.M{"expires_at": time.Now().Add(ttl)}}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, opts)
+	return err
+}
+
+// Holders filters on expires_at itself, as the TTL index only removes
+// expired leases about once a minute.
+func (r *LeaseRepository) Holders(ctx context.Context, name string) ([]string, error) {
+	filter := bson.M{"name": name, "expires_at": bson.M{"$gt": time.Now()}}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var leases []leaseDocument
+	if err := cursor.All(ctx, &leases); err != nil {
+		return nil, err
+	}
+
+	holders := make([]string, len(leases))
+	for i, lease := range leases {
+		holders[i] = lease.Holder
+	}
+	return holders, nil
+}
+
+func (r *LeaseRepository) Release(ctx context.Context, name, holder string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"name": name, "holder": holder})
+	return err
+}
+
+// EnsureIndexes creates the unique index that keeps one lease per holder and
+// name, and the TTL index that removes the leases of processes that died
+// without releasing them.
+func (r *LeaseRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}, {Key: "holder", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
@@ -0,0 +1,73 @@
+package mongo
+
+import (
+	"context"
+	"ledger-service/internal/core/types"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrateFloatAmounts rewrites monetary fields that are still stored as
+// floating point major units into integer minor units of the given currency.
+// Documents that were already migrated are left untouched, so it is safe to
+// run on every startup.
+func migrateFloatAmounts(ctx context.Context, collection *mongo.Collection, currency types.Currency, fields ...string) error {
+	for _, field := range fields {
+		filter := bson.M{field: bson.M{"$type": "double"}}
+		update := mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				field: bson.M{"$toLong": bson.M{"$round": bson.A{
+					bson.M{"$multiply": bson.A{"$" + field, currency.MinorUnitsPerMajor()}},
+					0, // $round uses half-to-even, matching types.RoundHalfEven
+				}}},
+			}}},
+		}
+
+		if _, err := collection.UpdateMany(ctx, filter, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillCurrency sets currency on documents written before multi-currency
+// support, which were implicitly denominated in it.
+func backfillCurrency(ctx context.Context, collection *mongo.Collection, currency types.Currency) error {
+	filter := bson.M{"currency": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"currency": currency}}
+
+	_, err := collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// MigrationLog remembers which one-off data migrations have completed.
+type MigrationLog struct {
+	collection *mongo.Collection
+}
+
+func NewMigrationLog(client *mongo.Client, dbName, collectionName string) *MigrationLog {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &MigrationLog{
+		collection: collection,
+	}
+}
+
+func (l *MigrationLog) Done(ctx context.Context, name string) (bool, error) {
+	count, err := l.collection.CountDocuments(ctx, bson.M{"name": name}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (l *MigrationLog) MarkDone(ctx context.Context, name string) error {
+	filter := bson.M{"name": name}
+	update := bson.M{"$set": bson.M{"name": name, "completed_at": time.Now()}}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := l.collection.UpdateOne(ctx, filter, update, opts)
+	return err
+}
@@ -0,0 +1,119 @@
+package mongo
+
+import (
+	"context"
+	"ledger-service/internal/core/types"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OutboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOutboxRepository(client *mongo.Client, dbName, collectionName string) *OutboxRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &OutboxRepository{
+		collection: collection,
+	}
+}
+
+func (r *OutboxRepository) Add(ctx context.Context, entry types.OutboxEntry) error {
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *OutboxRepository) ClaimNext(ctx context.Context, instance string) (types.OutboxEntry, bool, error) {
+	filter := bson.M{"dispatched_at": nil, "applied_at": nil}
+	update := bson.M{"$set": bson.M{"dispatched_at": time.Now(), "dispatched_by": instance}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var entry types.OutboxEntry
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return types.OutboxEntry{}, false, nil
+		}
+		return types.OutboxEntry{}, false, err
+	}
+	return entry, true, nil
+}
+
+func (r *OutboxRepository) ResetDispatched(ctx context.Context, keep []string) error {
+	if keep == nil {
+		keep = []string{}
+	}
+	filter := bson.M{
+		"applied_at":       nil,
+		"dead_lettered_at": nil,
+		"dispatched_at":    bson.M{"$ne": nil},
+		"dispatched_by":    bson.M{"$nin": keep},
+	}
+	update := bson.M{"$set": bson.M{"dispatched_at": nil}}
+
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+func (r *OutboxRepository) Redispatch(ctx context.Context, transactionId string) error {
+	filter := bson.M{"transaction_id": transactionId, "applied_at": nil, "dead_lettered_at": nil}
+	update := bson.M{"$set": bson.M{"dispatched_at": nil}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *OutboxRepository) RecordFailure(ctx context.Context, transactionId, reason string) (int, error) {
+	filter := bson.M{"transaction_id": transactionId}
+	update := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"last_error": reason},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var entry types.OutboxEntry
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&entry); err != nil {
+		return 0, err
+	}
+	return entry.Failures, nil
+}
+
+func (r *OutboxRepository) DeadLetter(ctx context.Context, transactionId string) error {
+	filter := bson.M{"transaction_id": transactionId, "applied_at": nil}
+	update := bson.M{"$set": bson.M{"dead_lettered_at": time.Now()}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *OutboxRepository) UnappliedTransactionIds(ctx context.Context) ([]string, error) {
+	opts := options.Find().SetProjection(bson.M{"transaction_id": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"applied_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	ids := []string{}
+	for cursor.Next(ctx) {
+		var entry types.OutboxEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return ids, err
+		}
+		ids = append(ids, entry.TransactionId)
+	}
+	return ids, cursor.Err()
+}
+
+func (r *OutboxRepository) MarkApplied(ctx context.Context, transactionId string) error {
+	filter := bson.M{"transaction_id": transactionId}
+	update := bson.M{"$set": bson.M{"applied_at": time.Now()}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
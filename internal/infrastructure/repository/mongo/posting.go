@@ -0,0 +1,278 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"ledger-service/internal/core/types"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PostingRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPostingRepository(client *mongo.Client, dbName, collectionName string) *PostingRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &PostingRepository{
+		collection: collection,
+	}
+}
+
+func (r *PostingRepository) SaveMany(ctx context.Context, postings []types.Posting) error {
+	if len(postings) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(postings))
+	for _, p := range postings {
+		documents = append(documents, p)
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents)
+	return err
+}
+
+func (r *PostingRepository) GetForTransaction(ctx context.Context, transactionId string) ([]types.Posting, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"transaction_id": transactionId}, opts)
+	if err != nil {
+		return []types.Posting{}, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []types.Posting{}
+	for cursor.Next(ctx) {
+		var posting types.Posting
+		if err := cursor.Decode(&posting); err != nil {
+			return results, err
+		}
+		results = append(results, posting)
+	}
+	return results, cursor.Err()
+}
+
+func (r *PostingRepository) SumByCurrency(ctx context.Context, account string, kind types.PostingKind, from, to time.Time) ([]types.AccountTotal, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"account":   account,
+			"kind":      kind,
+			"posted_at": bson.M{"$gte": from, "$lt": to},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$currency",
+			"amount":   bson.M{"$sum": "$amount"},
+			"postings": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return []types.AccountTotal{}, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []types.AccountTotal{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return []types.AccountTotal{}, err
+	}
+	return results, nil
+}
+
+func (r *PostingRepository) GetHistory(ctx context.Context, account string, currency types.Currency, from, to time.Time, after *types.Cursor, limit int) ([]types.Posting, error) {
+	filter := bson.M{"account": account}
+	if currency != "" {
+		filter["currency"] = currency
+	}
+
+	postedAt := bson.M{}
+	if !from.IsZero() {
+		postedAt["$gte"] = from
+	}
+	if !to.IsZero() {
+		postedAt["$lt"] = to
+	}
+	if len(postedAt) > 0 {
+		filter["posted_at"] = postedAt
+	}
+
+	if after != nil {
+		page, err := r.historyAfter(ctx, after)
+		if err != nil {
+			return []types.Posting{}, err
+		}
+		filter["$or"] = page
+	}
+
+	opts := options.Find().SetSort(historyOrder).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return []types.Posting{}, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []types.Posting{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return []types.Posting{}, err
+	}
+	return results, nil
+}
+
+// historyOrder lists an account's postings per currency in the order they
+// were applied. Postings without a sequence were booked before any that have
+// one, and are ordered by time.
+var historyOrder = bson.D{
+	{Key: "currency", Value: 1},
+	{Key: "sequence", Value: 1},
+	{Key: "posted_at", Value: 1},
+	{Key: "id", Value: 1},
+}
+
+// historyAfter matches the postings that come after the one the cursor points
+// at in historyOrder.
+func (r *PostingRepository) historyAfter(ctx context.Context, after *types.Cursor) (bson.A, error) {
+	var last types.Posting
+	if err := r.collection.FindOne(ctx, bson.M{"id": after.Id}).Decode(&last); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: posting %s not found", types.ErrInvalidCursor, after.Id)
+		}
+		return nil, err
+	}
+
+	page := bson.A{
+		bson.M{"currency": bson.M{"$gt": last.Currency}},
+		bson.M{"currency": last.Currency, "sequence": bson.M{"$gt": last.Sequence}},
+	}
+	if last.Sequence == 0 {
+		page = append(page,
+			bson.M{"currency": last.Currency, "sequence": nil, "posted_at": bson.M{"$gt": last.PostedAt}},
+			bson.M{"currency": last.Currency, "sequence": nil, "posted_at": last.PostedAt, "id": bson.M{"$gt": last.Id}},
+		)
+	}
+	return page, nil
+}
+
+func (r *PostingRepository) Totals(ctx context.Context, account string, currency types.Currency, from, to time.Time) ([]types.BalanceSnapshot, error) {
+	match := bson.M{}
+	postedAt := bson.M{}
+	if !from.IsZero() {
+		postedAt["$gt"] = from
+	}
+	if !to.IsZero() {
+		postedAt["$lte"] = to
+	}
+	if len(postedAt) > 0 {
+		match["posted_at"] = postedAt
+	}
+	if account != "" {
+		match["account"] = account
+	}
+	if currency != "" {
+		match["currency"] = currency
+	}
+
+	isEscrow := bson.M{"$eq": bson.A{"$escrow", true}}
+	isCommission := bson.M{"$eq": bson.A{"$kind", types.COMMISSION_POSTING}}
+	isPlatform := bson.M{"$eq": bson.A{"$platform", true}}
+	// Commission is earned by the platform and paid by everyone else.
+	commission := bson.M{"$cond": bson.A{isPlatform, "$amount", bson.M{"$multiply": bson.A{"$amount", -1}}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        bson.M{"account": "$account", "currency": "$currency"},
+			"amount":     bson.M{"$sum": bson.M{"$cond": bson.A{isEscrow, 0, "$amount"}}},
+			"escrow":     bson.M{"$sum": bson.M{"$cond": bson.A{isEscrow, "$amount", 0}}},
+			"commission": bson.M{"$sum": bson.M{"$cond": bson.A{isCommission, commission, 0}}},
+			"postings":   bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":        0,
+			"account":    "$_id.account",
+			"currency":   "$_id.currency",
+			"amount":     1,
+			"escrow":     1,
+			"commission": 1,
+			"postings":   1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "account", Value: 1}, {Key: "currency", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return []types.BalanceSnapshot{}, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []types.BalanceSnapshot{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return []types.BalanceSnapshot{}, err
+	}
+	return results, nil
+}
+
+// MigratePlatform marks the platform's side of commission postings written
+// before postings recorded it. platformAccounts are every account that
+// earned commission.
+func (r *PostingRepository) MigratePlatform(ctx context.Context, platformAccounts []string) error {
+	filter := bson.M{
+		"kind":     types.COMMISSION_POSTING,
+		"account":  bson.M{"$in": platformAccounts},
+		"platform": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"platform": true}}
+
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// Shadow returns a repository over an empty collection named after this one
+// with a _rebuild suffix, for the journal to be rebuilt in. Whatever an
+// earlier rebuild left there is dropped.
+func (r *PostingRepository) Shadow(ctx context.Context) (*PostingRepository, error) {
+	shadow := &PostingRepository{
+		collection: r.collection.Database().Collection(r.collection.Name() + "_rebuild"),
+	}
+
+	if err := shadow.collection.Drop(ctx); err != nil {
+		return nil, err
+	}
+	if err := shadow.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+	return shadow, nil
+}
+
+// Replace swaps shadow in for this collection, keeping the postings it
+// replaces as a backup; see replaceCollection.
+func (r *PostingRepository) Replace(ctx context.Context, shadow *PostingRepository) error {
+	return replaceCollection(ctx, r.collection, shadow.collection)
+}
+
+// Restore puts back the postings the last Replace replaced.
+func (r *PostingRepository) Restore(ctx context.Context) error {
+	return restoreCollection(ctx, r.collection)
+}
+
+func (r *PostingRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "transaction_id", Value: 1}}},
+		{Keys: bson.D{{Key: "account", Value: 1}, {Key: "currency", Value: 1}, {Key: "posted_at", Value: 1}}},
+		{Keys: bson.D{{Key: "posted_at", Value: 1}}},
+		{Keys: append(bson.D{{Key: "account", Value: 1}}, historyOrder...)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Balance history used to be listed by time alone.
+	if _, err := r.collection.Indexes().DropOne(ctx, "account_1_posted_at_1_id_1"); err != nil && !isIndexNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type unitOfWork struct {
+	client *mongo.Client
+}
+
+func (u unitOfWork) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Already inside a session: join it rather than starting a nested one.
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := u.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
@@ -2,7 +2,9 @@ package mongo
 
 import (
 	"context"
+	"fmt"
 	"ledger-service/internal/core/types"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -11,12 +13,14 @@ import (
 )
 
 type TransactionRepository struct {
+	unitOfWork
 	collection *mongo.Collection
 }
 
 func NewTransactionRepository(client *mongo.Client, dbName, collectionName string) *TransactionRepository {
 	collection := client.Database(dbName).Collection(collectionName)
 	return &TransactionRepository{
+		unitOfWork: unitOfWork{client: client},
 		collection: collection,
 	}
 }
@@ -35,41 +39,522 @@ func (r *TransactionRepository) Save(ctx context.Context, t types.Transaction) (
 	return t.Id, nil
 }
 
+func (r *TransactionRepository) GetById(ctx context.Context, id string) (types.Transaction, error) {
+	var transaction types.Transaction
+	err := r.collection.FindOne(ctx, bson.M{"id": id}).Decode(&transaction)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return types.Transaction{}, types.ErrTransactionNotFound
+		}
+		return types.Transaction{}, err
+	}
+	return transaction, nil
+}
 
-func (r *TransactionRepository) GetManyForCustomer(ctx context.Context, id string) ([]types.Transaction, error) {
-	opts := options.Find().SetSort(bson.D{{"created_at", -1}})
-	cursor, err := r.collection.Find(ctx, bson.M{"customer.id": id}, opts)
+func (r *TransactionRepository) GetByIds(ctx context.Context, ids []string) ([]types.Transaction, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"id": bson.M{"$in": ids}})
 	if err != nil {
 		return []types.Transaction{}, err
 	}
 	defer cursor.Close(ctx)
 
 	results := []types.Transaction{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return []types.Transaction{}, err
+	}
+	return results, nil
+}
+
+func (r *TransactionRepository) MarkPosted(ctx context.Context, id string, postedAt time.Time) (bool, error) {
+	return r.transition(ctx, id, bson.M{"status": types.POSTED, "posted_at": postedAt})
+}
+
+func (r *TransactionRepository) MarkFailed(ctx context.Context, id string, reason string) (bool, error) {
+	return r.transition(ctx, id, bson.M{"status": types.FAILED, "failure_reason": reason})
+}
+
+func (r *TransactionRepository) transition(ctx context.Context, id string, set bson.M) (bool, error) {
+	filter := bson.M{"id": id, "status": types.PENDING}
+	update := bson.M{"$set": set}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *TransactionRepository) TransitionPayout(ctx context.Context, id string, from []types.PayoutStatus, to types.PayoutStatus) (bool, error) {
+	filter := bson.M{
+		"id":            id,
+		"type":          types.PAYOUT,
+		"status":        types.PENDING,
+		"payout_status": bson.M{"$in": from},
+	}
+	update := bson.M{"$set": bson.M{"payout_status": to}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *TransactionRepository) ResolveHold(ctx context.Context, id string, to types.HoldStatus) (bool, error) {
+	filter := bson.M{"id": id, "type": types.HOLD, "hold_status": types.HOLD_ACTIVE}
+	update := bson.M{"$set": bson.M{"hold_status": to}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *TransactionRepository) GetExpiredHolds(ctx context.Context, now time.Time) ([]types.Transaction, error) {
+	filter := bson.M{"type": types.HOLD, "hold_status": types.HOLD_ACTIVE, "expires_at": bson.M{"$lte": now}}
+	opts := options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return []types.Transaction{}, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []types.Transaction{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return []types.Transaction{}, err
+	}
+	return results, nil
+}
+
+func (r *TransactionRepository) EscrowProceeds(ctx context.Context, purchaseId string, amount types.Money, releaseAt time.Time) error {
+	update := bson.M{"$set": bson.M{
+		"escrowed":          true,
+		"escrow_status":     types.ESCROW_HELD,
+		"escrow_amount":     amount,
+		"escrow_release_at": releaseAt,
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"id": purchaseId}, update)
+	return err
+}
+
+func (r *TransactionRepository) DebitEscrow(ctx context.Context, purchaseId string, amount types.Money) (bool, error) {
+	filter := bson.M{
+		"id":            purchaseId,
+		"escrow_status": types.ESCROW_HELD,
+		"escrow_amount": bson.M{"$gte": amount},
+	}
+	update := bson.M{"$inc": bson.M{"escrow_amount": -amount}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *TransactionRepository) RestoreEscrow(ctx context.Context, purchaseId string, amount types.Money) (bool, error) {
+	filter := bson.M{"id": purchaseId, "escrow_status": types.ESCROW_HELD}
+	update := bson.M{"$inc": bson.M{"escrow_amount": amount}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *TransactionRepository) MarkEscrowed(ctx context.Context, id string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": bson.M{"escrowed": true}})
+	return err
+}
+
+func (r *TransactionRepository) SetLegs(ctx context.Context, id string, legs []types.Leg) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": bson.M{"legs": legs}})
+	return err
+}
+
+func (r *TransactionRepository) CloseEscrow(ctx context.Context, purchaseId string, deliveredAt *time.Time) (types.Transaction, bool, error) {
+	set := bson.M{"escrow_status": types.ESCROW_RELEASED, "escrow_amount": 0}
+	if deliveredAt != nil {
+		set["delivered_at"] = *deliveredAt
+	}
+	filter := bson.M{"id": purchaseId, "escrow_status": types.ESCROW_HELD}
+
+	var before types.Transaction
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": set}).Decode(&before)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return types.Transaction{}, false, nil
+		}
+		return types.Transaction{}, false, err
+	}
+	return before, true, nil
+}
+
+func (r *TransactionRepository) GetEscrowDue(ctx context.Context, now time.Time) ([]types.Transaction, error) {
+	filter := bson.M{"escrow_status": types.ESCROW_HELD, "escrow_release_at": bson.M{"$lte": now}}
+	opts := options.Find().SetSort(bson.D{{Key: "escrow_release_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return []types.Transaction{}, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []types.Transaction{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return []types.Transaction{}, err
+	}
+	return results, nil
+}
+
+func (r *TransactionRepository) ReserveRefund(ctx context.Context, purchaseId string, amount types.Money) error {
+	refundable := bson.M{"$subtract": bson.A{"$amount", bson.M{"$ifNull": bson.A{"$refunded_amount", 0}}}}
+	filter := bson.M{
+		"id":       purchaseId,
+		"type":     types.PURCHASE,
+		"status":   types.POSTED,
+		"reversed": bson.M{"$ne": true},
+		"$expr":    bson.M{"$gte": bson.A{refundable, amount}},
+	}
+	update := bson.M{"$inc": bson.M{"refunded_amount": amount}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return types.ErrRefundExceedsPurchase
+	}
+	return nil
+}
+
+func (r *TransactionRepository) ReleaseRefund(ctx context.Context, purchaseId string, amount types.Money) error {
+	filter := bson.M{"id": purchaseId}
+	update := bson.M{"$inc": bson.M{"refunded_amount": -amount}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *TransactionRepository) ClaimReversal(ctx context.Context, id string) error {
+	filter := bson.M{
+		"id":              id,
+		"type":            bson.M{"$nin": bson.A{types.REVERSAL, types.HOLD, types.ESCROW_RELEASE}},
+		"status":          types.POSTED,
+		"reversed":        bson.M{"$ne": true},
+		"refunded_amount": bson.M{"$in": bson.A{nil, 0}},
+	}
+	update := bson.M{"$set": bson.M{"reversed": true}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// Tell why it could not be claimed.
+	current, err := r.GetById(ctx, id)
+	switch {
+	case err != nil:
+		return err
+	case current.Reversed:
+		return fmt.Errorf("%w: transaction %s", types.ErrAlreadyReversed, id)
+	case current.RefundedAmount != 0:
+		return fmt.Errorf("%w: purchase %s was refunded", types.ErrNotReversible, id)
+	default:
+		return fmt.Errorf("%w: transaction %s is a %s %s", types.ErrNotReversible, id, current.Status, current.Type)
+	}
+}
+
+func (r *TransactionRepository) ReleaseReversal(ctx context.Context, id string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$unset": bson.M{"reversed": ""}})
+	return err
+}
+
+func (r *TransactionRepository) GetRelated(ctx context.Context, id string) ([]types.Transaction, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"related_transaction": id}, opts)
+	if err != nil {
+		return []types.Transaction{}, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []types.Transaction{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return []types.Transaction{}, err
+	}
+	return results, nil
+}
+
+func (r *TransactionRepository) ForEachPosted(ctx context.Context, fn func(types.Transaction) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "id", Value: 1}})
+	filter := bson.M{"status": types.POSTED, "type": bson.M{"$ne": types.HOLD}}
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
 	for cursor.Next(ctx) {
 		var transaction types.Transaction
 		if err := cursor.Decode(&transaction); err != nil {
-			return results, err // Return partial results with error
+			return err
+		}
+		if err := fn(transaction); err != nil {
+			return err
 		}
-		results = append(results, transaction)
 	}
-	return results, cursor.Err()
+	return cursor.Err()
+}
+
+func (r *TransactionRepository) GetManyForCustomer(ctx context.Context, id string, filter types.TransactionFilter, after *types.Cursor, limit int) ([]types.Transaction, error) {
+	return r.list(ctx, customerParty(id), filter, after, limit)
+}
+
+func (r *TransactionRepository) GetManyForRestaurant(ctx context.Context, id string, filter types.TransactionFilter, after *types.Cursor, limit int) ([]types.Transaction, error) {
+	return r.list(ctx, restaurantParty(id), filter, after, limit)
+}
+
+func (r *TransactionRepository) EachForCustomer(ctx context.Context, id string, filter types.TransactionFilter, fn func(types.Transaction) error) error {
+	return r.each(ctx, customerParty(id), filter, fn)
+}
+
+func (r *TransactionRepository) EachForRestaurant(ctx context.Context, id string, filter types.TransactionFilter, fn func(types.Transaction) error) error {
+	return r.each(ctx, restaurantParty(id), filter, fn)
+}
+
+// customerParty matches a customer's transactions. Transfers are listed for
+// both the sending and the receiving customer.
+func customerParty(id string) bson.M {
+	return bson.M{"$or": bson.A{bson.M{"customer.id": id}, bson.M{"recipient.id": id}}}
 }
 
-func (r *TransactionRepository) GetManyForRestaurant(ctx context.Context, id string) ([]types.Transaction, error) {
-	opts := options.Find().SetSort(bson.D{{"created_at", -1}})
-	cursor, err := r.collection.Find(ctx, bson.M{"restaurant.id": id}, opts)
+// restaurantParty matches a restaurant's transactions. The platform
+// account's history is listed like a restaurant's.
+func restaurantParty(id string) bson.M {
+	return bson.M{"$or": bson.A{bson.M{"restaurant.id": id}, bson.M{"platform.id": id}}}
+}
+
+func (r *TransactionRepository) GetManyForCourier(ctx context.Context, id string, filter types.TransactionFilter, after *types.Cursor, limit int) ([]types.Transaction, error) {
+	return r.list(ctx, bson.M{"courier.id": id}, filter, after, limit)
+}
+
+// list returns the transactions of a party matching filter, newest first.
+// The sort on created_at and id is served by the party's compound index.
+func (r *TransactionRepository) list(ctx context.Context, party bson.M, filter types.TransactionFilter, after *types.Cursor, limit int) ([]types.Transaction, error) {
+	conditions := filterConditions(party, filter)
+
+	if after != nil {
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"created_at": bson.M{"$lt": after.At}},
+			bson.M{"created_at": after.At, "id": bson.M{"$lt": after.Id}},
+		}})
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, bson.M{"$and": conditions}, opts)
 	if err != nil {
 		return []types.Transaction{}, err
 	}
 	defer cursor.Close(ctx)
 
 	results := []types.Transaction{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return []types.Transaction{}, err
+	}
+	return results, nil
+}
+
+// each streams the transactions of a party matching filter to fn, oldest
+// first, one document at a time.
+func (r *TransactionRepository) each(ctx context.Context, party bson.M, filter types.TransactionFilter, fn func(types.Transaction) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"$and": filterConditions(party, filter)}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
 	for cursor.Next(ctx) {
 		var transaction types.Transaction
 		if err := cursor.Decode(&transaction); err != nil {
-			return results, err // Return partial results with error
+			return err
+		}
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func filterConditions(party bson.M, filter types.TransactionFilter) bson.A {
+	conditions := bson.A{party}
+
+	if len(filter.Types) > 0 {
+		conditions = append(conditions, bson.M{"type": bson.M{"$in": filter.Types}})
+	}
+
+	amount := bson.M{}
+	if filter.MinAmount > 0 {
+		amount["$gte"] = filter.MinAmount
+	}
+	if filter.MaxAmount > 0 {
+		amount["$lte"] = filter.MaxAmount
+	}
+	if len(amount) > 0 {
+		conditions = append(conditions, bson.M{"amount": amount})
+	}
+
+	createdAt := bson.M{}
+	if !filter.From.IsZero() {
+		createdAt["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		createdAt["$lt"] = filter.To
+	}
+	if len(createdAt) > 0 {
+		conditions = append(conditions, bson.M{"created_at": createdAt})
+	}
+	return conditions
+}
+
+func (r *TransactionRepository) PurchaseVolume(ctx context.Context, restaurantId string, currency types.Currency, from, to time.Time) (types.Money, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"type":          types.PURCHASE,
+			"status":        types.POSTED,
+			"restaurant.id": restaurantId,
+			"currency":      currency,
+			"created_at":    bson.M{"$gte": from, "$lt": to},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    nil,
+			"amount": bson.M{"$sum": "$amount"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Amount types.Money `bson:"amount"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Amount, nil
+}
+
+func (r *TransactionRepository) MigrateFloatAmounts(ctx context.Context, currency types.Currency) error {
+	return migrateFloatAmounts(ctx, r.collection, currency, "amount")
+}
+
+func (r *TransactionRepository) MigrateCurrency(ctx context.Context, currency types.Currency) error {
+	return backfillCurrency(ctx, r.collection, currency)
+}
+
+// MigrateStatus assigns a status to transactions written before statuses
+// existed. Those still waiting in the outbox are PENDING, all others were
+// already applied and are POSTED.
+func (r *TransactionRepository) MigrateStatus(ctx context.Context, pendingIds []string) error {
+	if _, err := r.collection.UpdateMany(ctx,
+		bson.M{"applied_at": bson.M{"$exists": true}},
+		bson.M{"$rename": bson.M{"applied_at": "posted_at"}},
+	); err != nil {
+		return err
+	}
+
+	if _, err := r.collection.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$exists": false}, "id": bson.M{"$in": pendingIds}},
+		bson.M{"$set": bson.M{"status": types.PENDING}},
+	); err != nil {
+		return err
+	}
+
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"status": types.POSTED}},
+	)
+	return err
+}
+
+// MigratePlatform records the platform account on commission transactions
+// written before the platform was a party to them.
+func (r *TransactionRepository) MigratePlatform(ctx context.Context, platformAccount string) error {
+	filter := bson.M{"type": types.COMMISSION, "platform.id": bson.M{"$in": bson.A{nil, ""}}}
+	update := bson.M{"$set": bson.M{"platform": types.User{Id: platformAccount, Type: types.PLATFORM}}}
+
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// MigrateEmptyParties removes the empty recipient, platform and courier
+// written on every transaction while they were stored inline rather than by
+// reference.
+func (r *TransactionRepository) MigrateEmptyParties(ctx context.Context) error {
+	for _, party := range []string{"recipient", "platform", "courier"} {
+		filter := bson.M{party + ".id": ""}
+		update := bson.M{"$unset": bson.M{party: ""}}
+
+		if _, err := r.collection.UpdateMany(ctx, filter, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlatformAccounts returns every platform account recorded on a
+// transaction.
+func (r *TransactionRepository) PlatformAccounts(ctx context.Context) ([]string, error) {
+	values, err := r.collection.Distinct(ctx, "platform.id", bson.M{"platform.id": bson.M{"$nin": bson.A{nil, ""}}})
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]string, 0, len(values))
+	for _, value := range values {
+		if account, ok := value.(string); ok {
+			accounts = append(accounts, account)
 		}
-		results = append(results, transaction)
 	}
-	return results, cursor.Err()
+	return accounts, nil
+}
+
+// MigrateCommissionRate records the rate on commission transactions created
+// while a single fixed rate applied to every restaurant.
+func (r *TransactionRepository) MigrateCommissionRate(ctx context.Context, rate types.Rate) error {
+	filter := bson.M{"type": types.COMMISSION, "commission_rate": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"commission_rate": rate}}
+
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// EnsureIndexes creates the compound indexes the transaction listings are
+// paginated with, one per party they are listed for.
+func (r *TransactionRepository) EnsureIndexes(ctx context.Context) error {
+	models := []mongo.IndexModel{}
+	for _, party := range []string{"customer.id", "recipient.id", "restaurant.id", "platform.id", "courier.id"} {
+		models = append(models, mongo.IndexModel{
+			Keys: bson.D{{Key: party, Value: 1}, {Key: "created_at", Value: -1}, {Key: "id", Value: -1}},
+		})
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, models)
+	return err
 }
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
+type RunReconciliationInput struct {
+	Body *RunReconciliationRequest `json:"body"`
+}
+
+type RunReconciliationRequest struct {
+	Repair bool `json:"repair,omitempty" doc:"Correct the drifted wallets instead of only reporting them"`
+}
+
+type RunReconciliationOutput struct {
+	Body ReconciliationResponse `json:"body"`
+}
+
+type ReconciliationResponse struct {
+	StartedAt  time.Time       `json:"startedAt" doc:"When the reconciliation started"`
+	FinishedAt time.Time       `json:"finishedAt" doc:"When the reconciliation finished"`
+	Repair     bool            `json:"repair" doc:"Whether drifted wallets were corrected"`
+	Wallets    int             `json:"wallets" doc:"Number of wallets checked"`
+	Drifts     []DriftResponse `json:"drifts" doc:"Wallets whose stored figures differ from their postings"`
+}
+
+type DriftResponse struct {
+	AccountId               string      `json:"accountId" doc:"Account ID of the drifted wallet"`
+	Currency                string      `json:"currency" doc:"ISO 4217 currency code"`
+	StoredAmount            types.Money `json:"storedAmount" doc:"Stored balance in minor units"`
+	ExpectedAmount          types.Money `json:"expectedAmount" doc:"Balance computed from the postings"`
+	StoredEscrow            types.Money `json:"storedEscrow" doc:"Stored escrow"`
+	ExpectedEscrow          types.Money `json:"expectedEscrow" doc:"Escrow computed from the postings"`
+	StoredTotalCommission   types.Money `json:"storedTotalCommission" doc:"Stored total commission"`
+	ExpectedTotalCommission types.Money `json:"expectedTotalCommission" doc:"Total commission computed from the postings"`
+	Repaired                bool        `json:"repaired" doc:"Whether the stored figures were corrected"`
+}
+
+func ToReconciliationResponse(report types.ReconciliationReport) ReconciliationResponse {
+	response := ReconciliationResponse{
+		StartedAt:  report.StartedAt,
+		FinishedAt: report.FinishedAt,
+		Repair:     report.Repair,
+		Wallets:    report.Wallets,
+		Drifts:     []DriftResponse{},
+	}
+
+	for _, drift := range report.Drifts {
+		response.Drifts = append(response.Drifts, DriftResponse{
+			AccountId:               drift.Account,
+			Currency:                string(drift.Currency),
+			StoredAmount:            drift.StoredAmount,
+			ExpectedAmount:          drift.ExpectedAmount,
+			StoredEscrow:            drift.StoredEscrow,
+			ExpectedEscrow:          drift.ExpectedEscrow,
+			StoredTotalCommission:   drift.StoredCommission,
+			ExpectedTotalCommission: drift.ExpectedCommission,
+			Repaired:                drift.Repaired,
+		})
+	}
+
+	return response
+}
+
+func (h *Handler) RunReconciliation(ctx context.Context, input *RunReconciliationInput) (*RunReconciliationOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	repair := input.Body != nil && input.Body.Repair
+	report, err := h.ledgerService.Reconcile(ctxWithTimeout, repair)
+	if err != nil {
+		if errors.Is(err, types.ErrReconciliationRunning) {
+			return nil, huma.Error409Conflict("Reconciliation already running", err)
+		}
+		return nil, huma.Error500InternalServerError("Reconciliation failed", err)
+	}
+
+	return &RunReconciliationOutput{
+		Body: ToReconciliationResponse(report),
+	}, nil
+}
@@ -2,6 +2,7 @@ package balance
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -9,7 +10,9 @@ import (
 )
 
 type GetBalanceInput struct {
-	UserId string `path:"userId" doc:"User ID"`
+	UserId   string         `path:"userId" doc:"User ID"`
+	Currency types.Currency `query:"currency" pattern:"^[A-Z]{3}$" doc:"Only return the balance in this ISO 4217 currency"`
+	AsOf     time.Time      `query:"asOf" doc:"Return the balances as they were at this instant (RFC 3339) instead of the current ones"`
 }
 
 type GetBalanceOutput struct {
@@ -17,15 +20,37 @@ type GetBalanceOutput struct {
 }
 
 type GetBalanceResponse struct {
-	UserId          string   `json:"userId" doc:"User ID"`
-	Amount          float32  `json:"amount" doc:"Current balance amount"`
-	TotalCommission *float32 `json:"totalCommission,omitempty" doc:"Total commission earned (restaurants only)"`
+	UserId   string                    `json:"userId" doc:"User ID"`
+	AsOf     *time.Time                `json:"asOf,omitempty" doc:"Instant the balances were computed at, when asOf was requested"`
+	Balances []CurrencyBalanceResponse `json:"balances" doc:"Balance per currency"`
 }
 
-func ToGetBalanceResponse(balance types.Balance) GetBalanceResponse {
-	response := GetBalanceResponse{
-		UserId: balance.UserId,
-		Amount: balance.Amount,
+type CurrencyBalanceResponse struct {
+	Currency        string       `json:"currency" doc:"ISO 4217 currency code"`
+	Amount          types.Money  `json:"amount" doc:"Current balance amount in minor units, same as ledger"`
+	Ledger          types.Money  `json:"ledger" doc:"Balance booked in the journal in minor units; holds and pending transactions do not change it"`
+	Reserved        types.Money  `json:"reserved" doc:"Amount reserved for accepted transactions that are not applied yet and for active holds"`
+	Available       types.Money  `json:"available" doc:"Balance amount not reserved for pending transactions or held"`
+	Escrow          *types.Money `json:"escrow,omitempty" doc:"Purchase proceeds held until delivery or the end of the clearing period, not included in amount (restaurants only)"`
+	OverdraftLimit  *types.Money `json:"overdraftLimit,omitempty" doc:"How far below zero the balance may be spent"`
+	TotalCommission *types.Money `json:"totalCommission,omitempty" doc:"Total commission earned in minor units (restaurants only)"`
+}
+
+func ToCurrencyBalanceResponse(balance types.Balance) CurrencyBalanceResponse {
+	response := CurrencyBalanceResponse{
+		Currency:  string(balance.Currency),
+		Amount:    balance.Amount,
+		Ledger:    balance.Ledger(),
+		Reserved:  balance.Reserved,
+		Available: balance.Available(),
+	}
+
+	if balance.Escrow != 0 {
+		response.Escrow = &balance.Escrow
+	}
+
+	if balance.OverdraftLimit > 0 {
+		response.OverdraftLimit = &balance.OverdraftLimit
 	}
 
 	// Include commission if user is a restaurant (has commission > 0)
@@ -36,18 +61,43 @@ func ToGetBalanceResponse(balance types.Balance) GetBalanceResponse {
 	return response
 }
 
+func ToGetBalanceResponse(userId string, balances []types.Balance) GetBalanceResponse {
+	response := GetBalanceResponse{
+		UserId:   userId,
+		Balances: []CurrencyBalanceResponse{},
+	}
+
+	for _, balance := range balances {
+		response.Balances = append(response.Balances, ToCurrencyBalanceResponse(balance))
+	}
+
+	return response
+}
+
 func (h *Handler) GetBalance(ctx context.Context, input *GetBalanceInput) (*GetBalanceOutput, error) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	balance, err := h.ledgerService.GetBalance(ctxWithTimeout, input.UserId)
+	var balances []types.Balance
+	var err error
+	if input.AsOf.IsZero() {
+		balances, err = h.ledgerService.GetBalances(ctxWithTimeout, input.UserId, input.Currency)
+	} else {
+		balances, err = h.ledgerService.GetBalancesAsOf(ctxWithTimeout, input.UserId, input.Currency, input.AsOf)
+	}
 	if err != nil {
+		if errors.Is(err, types.ErrUnsupportedCurrency) {
+			return nil, huma.Error422UnprocessableEntity("Unsupported currency", err)
+		}
 		return nil, huma.Error500InternalServerError("Internal server error", err)
 	}
 
-	response := ToGetBalanceResponse(balance)
+	response := ToGetBalanceResponse(input.UserId, balances)
+	if !input.AsOf.IsZero() {
+		response.AsOf = &input.AsOf
+	}
 
 	return &GetBalanceOutput{
 		Body: response,
 	}, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,88 @@
+package balance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
+type GetBalanceHistoryInput struct {
+	UserId   string         `path:"userId" doc:"User ID"`
+	Currency types.Currency `query:"currency" pattern:"^[A-Z]{3}$" doc:"Only return changes in this ISO 4217 currency"`
+	From     time.Time      `query:"from" doc:"Only return changes booked at or after this instant"`
+	To       time.Time      `query:"to" doc:"Only return changes booked before this instant"`
+	Cursor   string         `query:"cursor" doc:"Cursor returned in Next-Cursor by the previous page"`
+	Limit    int            `query:"limit" minimum:"1" maximum:"500" default:"50" doc:"Maximum number of changes to return"`
+}
+
+type GetBalanceHistoryOutput struct {
+	Next string                    `header:"Next-Cursor" doc:"Cursor for the next page; absent on the last page"`
+	Body GetBalanceHistoryResponse `json:"body"`
+}
+
+type GetBalanceHistoryResponse struct {
+	UserId  string                 `json:"userId" doc:"User ID"`
+	Entries []BalanceEntryResponse `json:"entries" doc:"Balance changes per currency, in the order they were applied"`
+}
+
+type BalanceEntryResponse struct {
+	PostingId     string       `json:"postingId" doc:"Journal posting that changed the balance"`
+	TransactionId string       `json:"transactionId" doc:"Transaction the posting belongs to"`
+	Currency      string       `json:"currency" doc:"ISO 4217 currency code"`
+	Amount        types.Money  `json:"amount" doc:"Signed change in minor units: positive credits, negative debits"`
+	Kind          string       `json:"kind" doc:"What the change is for, e.g. PRINCIPAL or COMMISSION"`
+	Escrow        bool         `json:"escrow,omitempty" doc:"Whether the change is to the escrow rather than the balance"`
+	BalanceAfter  *types.Money `json:"balanceAfter,omitempty" doc:"Balance right after the change; absent for changes recorded before balances were tracked per posting"`
+	EscrowAfter   *types.Money `json:"escrowAfter,omitempty" doc:"Escrow right after the change, if the account has any"`
+	PostedAt      time.Time    `json:"postedAt" doc:"When the change was booked"`
+}
+
+func ToBalanceEntryResponse(p types.Posting) BalanceEntryResponse {
+	return BalanceEntryResponse{
+		PostingId:     p.Id,
+		TransactionId: p.TransactionId,
+		Currency:      string(p.Currency),
+		Amount:        p.Amount,
+		Kind:          string(p.Kind),
+		Escrow:        p.Escrow,
+		BalanceAfter:  p.BalanceAfter,
+		EscrowAfter:   p.EscrowAfter,
+		PostedAt:      p.PostedAt,
+	}
+}
+
+func (h *Handler) GetBalanceHistory(ctx context.Context, input *GetBalanceHistoryInput) (*GetBalanceHistoryOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if !input.From.IsZero() && !input.To.IsZero() && !input.From.Before(input.To) {
+		return nil, huma.Error400BadRequest("from must be before to")
+	}
+
+	postings, next, err := h.ledgerService.GetBalanceHistory(ctxWithTimeout, input.UserId, input.Currency, input.From, input.To, input.Cursor, input.Limit)
+	if err != nil {
+		if errors.Is(err, types.ErrUnsupportedCurrency) {
+			return nil, huma.Error422UnprocessableEntity("Unsupported currency", err)
+		}
+		if errors.Is(err, types.ErrInvalidCursor) {
+			return nil, huma.Error400BadRequest("Invalid cursor", err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to retrieve balance history", err)
+	}
+
+	response := GetBalanceHistoryResponse{
+		UserId:  input.UserId,
+		Entries: []BalanceEntryResponse{},
+	}
+	for _, posting := range postings {
+		response.Entries = append(response.Entries, ToBalanceEntryResponse(posting))
+	}
+
+	return &GetBalanceHistoryOutput{
+		Next: next,
+		Body: response,
+	}, nil
+}
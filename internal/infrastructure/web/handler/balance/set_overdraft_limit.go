@@ -0,0 +1,41 @@
+package balance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
+type SetOverdraftLimitRequest struct {
+	Currency types.Currency `json:"currency" pattern:"^[A-Z]{3}$" doc:"ISO 4217 currency code of the wallet"`
+	Limit    types.Money    `json:"limit" minimum:"0" doc:"How far below zero the wallet may be spent, in minor units"`
+}
+
+type SetOverdraftLimitInput struct {
+	UserId string                   `path:"userId" doc:"User ID"`
+	Body   SetOverdraftLimitRequest `json:"body"`
+}
+
+type SetOverdraftLimitOutput struct {
+	Body CurrencyBalanceResponse `json:"body"`
+}
+
+func (h *Handler) SetOverdraftLimit(ctx context.Context, input *SetOverdraftLimitInput) (*SetOverdraftLimitOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	balance, err := h.ledgerService.SetOverdraftLimit(ctxWithTimeout, input.UserId, input.Body.Currency, input.Body.Limit)
+	if err != nil {
+		if errors.Is(err, types.ErrUnsupportedCurrency) {
+			return nil, huma.Error422UnprocessableEntity("Unsupported currency", err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to set overdraft limit", err)
+	}
+
+	return &SetOverdraftLimitOutput{
+		Body: ToCurrencyBalanceResponse(balance),
+	}, nil
+}
@@ -0,0 +1,17 @@
+package commission
+
+import (
+	"context"
+	"time"
+)
+
+func (h *Handler) DeleteRestaurantPolicy(ctx context.Context, input *RestaurantPolicyInput) (*struct{}, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := h.ledgerService.DeleteCommissionPolicy(ctxWithTimeout, input.RestaurantId); err != nil {
+		return nil, toPolicyError("Failed to delete commission policy", err)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,24 @@
+package commission
+
+import (
+	"context"
+	"time"
+)
+
+type PolicyOutput struct {
+	Body PolicyResponse `json:"body"`
+}
+
+func (h *Handler) GetDefaultPolicy(ctx context.Context, input *struct{}) (*PolicyOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	policy, err := h.ledgerService.GetDefaultCommissionPolicy(ctxWithTimeout)
+	if err != nil {
+		return nil, toPolicyError("Failed to retrieve default commission policy", err)
+	}
+
+	return &PolicyOutput{
+		Body: ToPolicyResponse(policy),
+	}, nil
+}
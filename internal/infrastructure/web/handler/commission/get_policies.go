@@ -0,0 +1,45 @@
+package commission
+
+import (
+	"context"
+	"time"
+)
+
+type GetPoliciesOutput struct {
+	Body GetPoliciesResponse `json:"body"`
+}
+
+type GetPoliciesResponse struct {
+	Default  PolicyResponse   `json:"default" doc:"Policy applied to restaurants without their own"`
+	Policies []PolicyResponse `json:"policies" doc:"Per-restaurant policies"`
+}
+
+func (h *Handler) GetPolicies(ctx context.Context, input *struct{}) (*GetPoliciesOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	defaultPolicy, err := h.ledgerService.GetDefaultCommissionPolicy(ctxWithTimeout)
+	if err != nil {
+		return nil, toPolicyError("Failed to retrieve commission policies", err)
+	}
+
+	policies, err := h.ledgerService.GetCommissionPolicies(ctxWithTimeout)
+	if err != nil {
+		return nil, toPolicyError("Failed to retrieve commission policies", err)
+	}
+
+	response := GetPoliciesResponse{
+		Default:  ToPolicyResponse(defaultPolicy),
+		Policies: []PolicyResponse{},
+	}
+	for _, policy := range policies {
+		if policy.IsDefault() {
+			continue
+		}
+		response.Policies = append(response.Policies, ToPolicyResponse(policy))
+	}
+
+	return &GetPoliciesOutput{
+		Body: response,
+	}, nil
+}
@@ -0,0 +1,24 @@
+package commission
+
+import (
+	"context"
+	"time"
+)
+
+type RestaurantPolicyInput struct {
+	RestaurantId string `path:"restaurantId" minLength:"1" doc:"Restaurant ID"`
+}
+
+func (h *Handler) GetRestaurantPolicy(ctx context.Context, input *RestaurantPolicyInput) (*PolicyOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	policy, err := h.ledgerService.GetCommissionPolicy(ctxWithTimeout, input.RestaurantId)
+	if err != nil {
+		return nil, toPolicyError("Failed to retrieve commission policy", err)
+	}
+
+	return &PolicyOutput{
+		Body: ToPolicyResponse(policy),
+	}, nil
+}
@@ -0,0 +1,24 @@
+package commission
+
+import (
+	"context"
+	"time"
+)
+
+type SetDefaultPolicyInput struct {
+	Body SetPolicyRequest `json:"body"`
+}
+
+func (h *Handler) SetDefaultPolicy(ctx context.Context, input *SetDefaultPolicyInput) (*PolicyOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	policy, err := h.ledgerService.SetCommissionPolicy(ctxWithTimeout, "", input.Body.Rate, input.Body.ToSchedule())
+	if err != nil {
+		return nil, toPolicyError("Failed to set default commission policy", err)
+	}
+
+	return &PolicyOutput{
+		Body: ToPolicyResponse(policy),
+	}, nil
+}
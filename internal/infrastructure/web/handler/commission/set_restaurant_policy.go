@@ -0,0 +1,25 @@
+package commission
+
+import (
+	"context"
+	"time"
+)
+
+type SetRestaurantPolicyInput struct {
+	RestaurantId string           `path:"restaurantId" minLength:"1" doc:"Restaurant ID"`
+	Body         SetPolicyRequest `json:"body"`
+}
+
+func (h *Handler) SetRestaurantPolicy(ctx context.Context, input *SetRestaurantPolicyInput) (*PolicyOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	policy, err := h.ledgerService.SetCommissionPolicy(ctxWithTimeout, input.RestaurantId, input.Body.Rate, input.Body.ToSchedule())
+	if err != nil {
+		return nil, toPolicyError("Failed to set commission policy", err)
+	}
+
+	return &PolicyOutput{
+		Body: ToPolicyResponse(policy),
+	}, nil
+}
@@ -0,0 +1,119 @@
+package commission
+
+import (
+	"errors"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
+type SetPolicyRequest struct {
+	Rate     types.Rate    `json:"rate" minimum:"0" maximum:"10000" doc:"Commission rate in basis points (500 is 5%), charged when no schedule rule is in effect"`
+	Schedule []RuleRequest `json:"schedule,omitempty" doc:"Effective-dated rules; at most one may apply to any purchase"`
+}
+
+type RuleRequest struct {
+	ValidFrom time.Time      `json:"validFrom,omitempty" doc:"Start of the validity period (inclusive), open-ended if omitted"`
+	ValidTo   *time.Time     `json:"validTo,omitempty" doc:"End of the validity period (exclusive), open-ended if omitted"`
+	Currency  types.Currency `json:"currency,omitempty" pattern:"^[A-Z]{3}$" doc:"Only apply to purchases in this ISO 4217 currency; required for tier volumes and fee bounds"`
+	Tiers     []TierRequest  `json:"tiers" minItems:"1" doc:"Rates by monthly gross purchase volume, the first starting at 0"`
+	MinFee    types.Money    `json:"minFee,omitempty" minimum:"0" doc:"Minimum fee per purchase in minor units"`
+	MaxFee    types.Money    `json:"maxFee,omitempty" minimum:"0" doc:"Maximum fee per purchase in minor units, uncapped if omitted"`
+}
+
+type TierRequest struct {
+	MinVolume types.Money `json:"minVolume" minimum:"0" doc:"Gross purchase volume earlier in the calendar month (UTC) from which the tier applies, in minor units"`
+	Rate      types.Rate  `json:"rate" minimum:"0" maximum:"10000" doc:"Commission rate in basis points"`
+}
+
+type PolicyResponse struct {
+	RestaurantId string         `json:"restaurantId,omitempty" doc:"Restaurant the policy applies to, empty for the default policy"`
+	Default      bool           `json:"default" doc:"Whether this is the default policy for restaurants without their own"`
+	Rate         types.Rate     `json:"rate" doc:"Commission rate in basis points (500 is 5%), charged when no schedule rule is in effect"`
+	Schedule     []RuleResponse `json:"schedule" doc:"Effective-dated rules"`
+	UpdatedAt    *time.Time     `json:"updatedAt,omitempty" doc:"When the policy was last changed, absent for the built-in default"`
+}
+
+type RuleResponse struct {
+	ValidFrom *time.Time     `json:"validFrom,omitempty" doc:"Start of the validity period (inclusive)"`
+	ValidTo   *time.Time     `json:"validTo,omitempty" doc:"End of the validity period (exclusive)"`
+	Currency  string         `json:"currency,omitempty" doc:"ISO 4217 currency code the rule is restricted to"`
+	Tiers     []TierResponse `json:"tiers" doc:"Rates by monthly gross purchase volume"`
+	MinFee    types.Money    `json:"minFee" doc:"Minimum fee per purchase in minor units"`
+	MaxFee    *types.Money   `json:"maxFee,omitempty" doc:"Maximum fee per purchase in minor units"`
+}
+
+type TierResponse struct {
+	MinVolume types.Money `json:"minVolume" doc:"Monthly gross purchase volume from which the tier applies, in minor units"`
+	Rate      types.Rate  `json:"rate" doc:"Commission rate in basis points"`
+}
+
+func (r SetPolicyRequest) ToSchedule() []types.CommissionRule {
+	schedule := []types.CommissionRule{}
+	for _, rule := range r.Schedule {
+		tiers := []types.CommissionTier{}
+		for _, tier := range rule.Tiers {
+			tiers = append(tiers, types.CommissionTier{MinVolume: tier.MinVolume, Rate: tier.Rate})
+		}
+
+		schedule = append(schedule, types.CommissionRule{
+			ValidFrom: rule.ValidFrom,
+			ValidTo:   rule.ValidTo,
+			Currency:  rule.Currency,
+			Tiers:     tiers,
+			MinFee:    rule.MinFee,
+			MaxFee:    rule.MaxFee,
+		})
+	}
+	return schedule
+}
+
+func ToPolicyResponse(policy types.CommissionPolicy) PolicyResponse {
+	response := PolicyResponse{
+		RestaurantId: policy.RestaurantId,
+		Default:      policy.IsDefault(),
+		Rate:         policy.Rate,
+		Schedule:     []RuleResponse{},
+	}
+
+	if !policy.UpdatedAt.IsZero() {
+		response.UpdatedAt = &policy.UpdatedAt
+	}
+
+	for _, rule := range policy.Schedule {
+		ruleResponse := RuleResponse{
+			ValidTo:  rule.ValidTo,
+			Currency: string(rule.Currency),
+			Tiers:    []TierResponse{},
+			MinFee:   rule.MinFee,
+		}
+		if !rule.ValidFrom.IsZero() {
+			validFrom := rule.ValidFrom
+			ruleResponse.ValidFrom = &validFrom
+		}
+		if rule.MaxFee > 0 {
+			maxFee := rule.MaxFee
+			ruleResponse.MaxFee = &maxFee
+		}
+		for _, tier := range rule.Tiers {
+			ruleResponse.Tiers = append(ruleResponse.Tiers, TierResponse{MinVolume: tier.MinVolume, Rate: tier.Rate})
+		}
+		response.Schedule = append(response.Schedule, ruleResponse)
+	}
+
+	return response
+}
+
+// toPolicyError maps errors returned by the commission policy operations to
+// HTTP errors.
+func toPolicyError(msg string, err error) error {
+	switch {
+	case errors.Is(err, types.ErrCommissionPolicyNotFound):
+		return huma.Error404NotFound(msg, err)
+	case errors.Is(err, types.ErrInvalidCommissionRate), errors.Is(err, types.ErrInvalidCommissionSchedule):
+		return huma.Error422UnprocessableEntity(msg, err)
+	default:
+		return huma.Error500InternalServerError(msg, err)
+	}
+}
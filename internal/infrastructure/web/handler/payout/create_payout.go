@@ -0,0 +1,43 @@
+package payout
+
+import (
+	"context"
+	"time"
+
+	"ledger-service/internal/core/types"
+)
+
+type CreatePayoutRequest struct {
+	Amount   types.Money    `json:"amount" minimum:"1" doc:"Amount to pay out in minor units"`
+	Currency types.Currency `json:"currency,omitempty" pattern:"^[A-Z]{3}$" doc:"ISO 4217 currency code of the wallet to pay out from; defaults to the service currency"`
+}
+
+type CreatePayoutInput struct {
+	RestaurantId   string              `path:"restaurantId" doc:"Restaurant ID"`
+	IdempotencyKey string              `header:"Idempotency-Key" maxLength:"255" doc:"Client generated key that makes retries of this request safe"`
+	Body           CreatePayoutRequest `json:"body"`
+}
+
+type CreatePayoutOutput struct {
+	Replayed string         `header:"Idempotent-Replayed" doc:"Set to true when the response is replayed for a repeated Idempotency-Key"`
+	Body     PayoutResponse `json:"body"`
+}
+
+func (h *Handler) CreatePayout(ctx context.Context, input *CreatePayoutInput) (*CreatePayoutOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	saved, replayed, err := h.ledgerService.RequestPayout(ctxWithTimeout, input.RestaurantId, input.Body.Amount, input.Body.Currency, input.IdempotencyKey)
+	if err != nil {
+		return nil, toPayoutError("Failed to request payout", err)
+	}
+
+	output := &CreatePayoutOutput{
+		Body: ToPayoutResponse(saved),
+	}
+	if replayed {
+		output.Replayed = "true"
+	}
+
+	return output, nil
+}
@@ -0,0 +1,20 @@
+package payout
+
+import (
+	"context"
+	"time"
+)
+
+func (h *Handler) GetPayout(ctx context.Context, input *PayoutInput) (*PayoutOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	payout, err := h.ledgerService.GetPayout(ctxWithTimeout, input.RestaurantId, input.PayoutId)
+	if err != nil {
+		return nil, toPayoutError("Failed to retrieve payout", err)
+	}
+
+	return &PayoutOutput{
+		Body: ToPayoutResponse(payout),
+	}, nil
+}
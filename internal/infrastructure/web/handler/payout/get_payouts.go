@@ -0,0 +1,33 @@
+package payout
+
+import (
+	"context"
+	"time"
+)
+
+type GetPayoutsInput struct {
+	RestaurantId string `path:"restaurantId" doc:"Restaurant ID"`
+}
+
+type GetPayoutsOutput struct {
+	Body []PayoutResponse `json:"body"`
+}
+
+func (h *Handler) GetPayouts(ctx context.Context, input *GetPayoutsInput) (*GetPayoutsOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	payouts, err := h.ledgerService.GetPayouts(ctxWithTimeout, input.RestaurantId)
+	if err != nil {
+		return nil, toPayoutError("Failed to retrieve payouts", err)
+	}
+
+	responses := []PayoutResponse{}
+	for _, payout := range payouts {
+		responses = append(responses, ToPayoutResponse(payout))
+	}
+
+	return &GetPayoutsOutput{
+		Body: responses,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package payout
+
+import (
+	"context"
+	"time"
+)
+
+type RejectPayoutRequest struct {
+	Reason string `json:"reason" minLength:"1" maxLength:"1000" doc:"Why the payout is rejected"`
+}
+
+type RejectPayoutInput struct {
+	RestaurantId string              `path:"restaurantId" doc:"Restaurant ID"`
+	PayoutId     string              `path:"payoutId" doc:"Payout ID"`
+	Body         RejectPayoutRequest `json:"body"`
+}
+
+func (h *Handler) RejectPayout(ctx context.Context, input *RejectPayoutInput) (*PayoutOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	payout, err := h.ledgerService.RejectPayout(ctxWithTimeout, input.RestaurantId, input.PayoutId, input.Body.Reason)
+	if err != nil {
+		return nil, toPayoutError("Failed to reject payout", err)
+	}
+
+	return &PayoutOutput{
+		Body: ToPayoutResponse(payout),
+	}, nil
+}
@@ -0,0 +1,20 @@
+package payout
+
+import (
+	"context"
+	"time"
+)
+
+func (h *Handler) SettlePayout(ctx context.Context, input *PayoutInput) (*PayoutOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	payout, err := h.ledgerService.SettlePayout(ctxWithTimeout, input.RestaurantId, input.PayoutId)
+	if err != nil {
+		return nil, toPayoutError("Failed to settle payout", err)
+	}
+
+	return &PayoutOutput{
+		Body: ToPayoutResponse(payout),
+	}, nil
+}
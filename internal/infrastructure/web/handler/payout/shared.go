@@ -0,0 +1,62 @@
+package payout
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
+type PayoutInput struct {
+	RestaurantId string `path:"restaurantId" doc:"Restaurant ID"`
+	PayoutId     string `path:"payoutId" doc:"Payout ID"`
+}
+
+type PayoutOutput struct {
+	Body PayoutResponse `json:"body"`
+}
+
+type PayoutResponse struct {
+	Id            string      `json:"id" doc:"Payout transaction ID"`
+	RestaurantId  string      `json:"restaurantId" doc:"Restaurant paid out"`
+	Amount        types.Money `json:"amount" doc:"Payout amount in minor units"`
+	Currency      string      `json:"currency" doc:"ISO 4217 currency code"`
+	PayoutStatus  string      `json:"payoutStatus" doc:"Workflow status: REQUESTED, APPROVED, SETTLED or REJECTED"`
+	Status        string      `json:"status" doc:"Transaction status: PENDING until the settled payout is applied to the balance, then POSTED, or FAILED when rejected"`
+	FailureReason string      `json:"failureReason,omitempty" doc:"Why the payout was rejected or failed"`
+	CreatedAt     time.Time   `json:"createdAt" doc:"When the payout was requested"`
+	PostedAt      *time.Time  `json:"postedAt,omitempty" doc:"When the payout left the restaurant's balance"`
+}
+
+func ToPayoutResponse(t types.Transaction) PayoutResponse {
+	return PayoutResponse{
+		Id:            t.Id,
+		RestaurantId:  t.Restaurant.Id,
+		Amount:        t.Amount,
+		Currency:      string(t.Currency),
+		PayoutStatus:  string(t.PayoutStatus),
+		Status:        string(t.Status),
+		FailureReason: t.FailureReason,
+		CreatedAt:     t.CreatedAt,
+		PostedAt:      t.PostedAt,
+	}
+}
+
+// toPayoutError maps errors returned by the payout operations to HTTP
+// errors.
+func toPayoutError(msg string, err error) error {
+	switch {
+	case errors.Is(err, types.ErrPayoutNotFound):
+		return huma.Error404NotFound(msg, err)
+	case errors.Is(err, types.ErrPayoutStatusConflict), errors.Is(err, types.ErrIdempotencyKeyInProgress):
+		return huma.Error409Conflict(msg, err)
+	case errors.Is(err, types.ErrUnsupportedCurrency), errors.Is(err, types.ErrIdempotencyKeyReused):
+		return huma.Error422UnprocessableEntity(msg, err)
+	case errors.Is(err, types.ErrInsufficientFunds):
+		return huma.NewError(http.StatusPaymentRequired, msg, err)
+	default:
+		return huma.Error500InternalServerError(msg, err)
+	}
+}
@@ -0,0 +1,67 @@
+package platform
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
+type GetRevenueInput struct {
+	From time.Time `query:"from" doc:"Start of the reporting period (inclusive), defaults to the beginning of time"`
+	To   time.Time `query:"to" doc:"End of the reporting period (exclusive), defaults to now"`
+}
+
+type GetRevenueOutput struct {
+	Body GetRevenueResponse `json:"body"`
+}
+
+type GetRevenueResponse struct {
+	AccountId string            `json:"accountId" doc:"Platform account ID; its balance and transactions are available through the balance and restaurant transaction endpoints"`
+	From      time.Time         `json:"from" doc:"Start of the reporting period (inclusive)"`
+	To        time.Time         `json:"to" doc:"End of the reporting period (exclusive)"`
+	Revenue   []RevenueResponse `json:"revenue" doc:"Commission earned per currency"`
+}
+
+type RevenueResponse struct {
+	Currency    string      `json:"currency" doc:"ISO 4217 currency code"`
+	Amount      types.Money `json:"amount" doc:"Net commission earned in minor units"`
+	Commissions int64       `json:"commissions" doc:"Number of commission postings"`
+}
+
+func (h *Handler) GetRevenue(ctx context.Context, input *GetRevenueInput) (*GetRevenueOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	to := input.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if !input.From.Before(to) {
+		return nil, huma.Error400BadRequest("from must be before to")
+	}
+
+	totals, err := h.ledgerService.GetPlatformRevenue(ctxWithTimeout, input.From, to)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to compute platform revenue", err)
+	}
+
+	response := GetRevenueResponse{
+		AccountId: h.ledgerService.PlatformAccount(),
+		From:      input.From,
+		To:        to,
+		Revenue:   []RevenueResponse{},
+	}
+	for _, total := range totals {
+		response.Revenue = append(response.Revenue, RevenueResponse{
+			Currency:    string(total.Currency),
+			Amount:      total.Amount,
+			Commissions: total.Postings,
+		})
+	}
+
+	return &GetRevenueOutput{
+		Body: response,
+	}, nil
+}
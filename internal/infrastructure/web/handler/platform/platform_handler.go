@@ -0,0 +1,15 @@
+package platform
+
+import (
+	"ledger-service/internal/core/services/ledger"
+)
+
+type Handler struct {
+	ledgerService *ledger.Service
+}
+
+func NewHandler(ledgerService *ledger.Service) *Handler {
+	return &Handler{
+		ledgerService: ledgerService,
+	}
+}
@@ -0,0 +1,127 @@
+package statement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
+type GetRestaurantStatementInput struct {
+	RestaurantId string         `path:"restaurantId" doc:"Restaurant ID"`
+	Period       string         `path:"period" pattern:"^[0-9]{4}-[0-9]{2}$" doc:"Calendar month (UTC) as YYYY-MM"`
+	Currency     types.Currency `query:"currency" pattern:"^[A-Z]{3}$" doc:"ISO 4217 currency of the statement, defaults to the default currency"`
+	Format       string         `query:"format" enum:"json,html" doc:"Response format; overrides the Accept header"`
+	Accept       string         `header:"Accept" doc:"application/json (the default) or text/html for a printable statement"`
+}
+
+type GetRestaurantStatementOutput struct {
+	ContentType string `header:"Content-Type"`
+	// Body is a StatementResponse, or the rendered HTML statement.
+	Body any
+}
+
+type StatementResponse struct {
+	RestaurantId       string      `json:"restaurantId" doc:"Restaurant ID"`
+	Currency           string      `json:"currency" doc:"ISO 4217 currency code"`
+	Period             string      `json:"period" doc:"Calendar month as YYYY-MM"`
+	From               time.Time   `json:"from" doc:"Start of the statement (inclusive)"`
+	To                 time.Time   `json:"to" doc:"End of the statement (exclusive); the time it was generated while the period is running"`
+	Final              bool        `json:"final" doc:"False while the period is still running and its figures can change"`
+	OpeningBalance     types.Money `json:"openingBalance" doc:"Balance at the start of the period in minor units, including proceeds held in escrow"`
+	OpeningEscrow      types.Money `json:"openingEscrow" doc:"Part of the opening balance held in escrow"`
+	GrossPurchases     types.Money `json:"grossPurchases" doc:"Purchase proceeds credited to the restaurant"`
+	Purchases          int64       `json:"purchases" doc:"Number of purchases credited to the restaurant"`
+	CommissionDeducted types.Money `json:"commissionDeducted" doc:"Commission charged, net of commission refunded"`
+	Refunds            types.Money `json:"refunds" doc:"Purchase refunds debited from the restaurant"`
+	Payouts            types.Money `json:"payouts" doc:"Payouts paid out of the balance"`
+	Adjustments        types.Money `json:"adjustments" doc:"Reversals and other movements; positive when credited"`
+	ClosingBalance     types.Money `json:"closingBalance" doc:"Opening balance plus gross purchases and adjustments, minus commission, refunds and payouts"`
+	ClosingEscrow      types.Money `json:"closingEscrow" doc:"Part of the closing balance held in escrow"`
+}
+
+func ToStatementResponse(statement types.Statement) StatementResponse {
+	return StatementResponse{
+		RestaurantId:       statement.RestaurantId,
+		Currency:           string(statement.Currency),
+		Period:             statement.Period.String(),
+		From:               statement.From,
+		To:                 statement.To,
+		Final:              statement.Final,
+		OpeningBalance:     statement.OpeningBalance,
+		OpeningEscrow:      statement.OpeningEscrow,
+		GrossPurchases:     statement.GrossPurchases,
+		Purchases:          statement.Purchases,
+		CommissionDeducted: statement.CommissionDeducted,
+		Refunds:            statement.Refunds,
+		Payouts:            statement.Payouts,
+		Adjustments:        statement.Adjustments,
+		ClosingBalance:     statement.ClosingBalance,
+		ClosingEscrow:      statement.ClosingEscrow,
+	}
+}
+
+func (h *Handler) GetRestaurantStatement(ctx context.Context, input *GetRestaurantStatementInput) (*GetRestaurantStatementOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	period, err := types.ParsePeriod(input.Period)
+	if err != nil {
+		return nil, huma.Error400BadRequest("Invalid period", err)
+	}
+
+	statement, err := h.ledgerService.GetRestaurantStatement(ctxWithTimeout, input.RestaurantId, input.Currency, period)
+	if err != nil {
+		switch {
+		case errors.Is(err, types.ErrInvalidPeriod):
+			return nil, huma.Error400BadRequest("Invalid period", err)
+		case errors.Is(err, types.ErrUnsupportedCurrency):
+			return nil, huma.Error422UnprocessableEntity("Unsupported currency", err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to build statement", err)
+	}
+
+	if !wantsHTML(input.Format, input.Accept) {
+		return &GetRestaurantStatementOutput{
+			Body: ToStatementResponse(statement),
+		}, nil
+	}
+
+	var page bytes.Buffer
+	if err := renderHTML(&page, statement); err != nil {
+		return nil, huma.Error500InternalServerError("Failed to render statement", err)
+	}
+	return &GetRestaurantStatementOutput{
+		ContentType: "text/html; charset=utf-8",
+		Body:        page.Bytes(),
+	}, nil
+}
+
+// wantsHTML reports whether the statement should be rendered as HTML: when
+// asked for with the format parameter, or else when text/html comes before
+// any JSON media type in the Accept header, as it does for browsers.
+func wantsHTML(format, accept string) bool {
+	if format != "" {
+		return format == "html"
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case mediaType == "text/html":
+			return true
+		case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+			return false
+		}
+	}
+	return false
+}
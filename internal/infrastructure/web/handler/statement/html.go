@@ -0,0 +1,76 @@
+package statement
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"ledger-service/internal/core/types"
+)
+
+var statementTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"amount": formatAmount,
+	"date":   func(t time.Time) string { return t.Format("2 January 2006") },
+	// To is exclusive, so the last day covered is the one just before it.
+	"through": func(t time.Time) string { return t.Add(-time.Nanosecond).Format("2 January 2006") },
+	"neg":     func(m types.Money) types.Money { return -m },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Statement {{.Period}} - {{.RestaurantId}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; max-width: 42rem; margin: 2rem auto; }
+h1 { font-size: 1.5rem; margin-bottom: 0.25rem; }
+p.meta { color: #555; margin-top: 0; }
+p.draft { border: 1px solid #c90; background: #fff8e0; padding: 0.5rem; }
+table { width: 100%; border-collapse: collapse; margin-top: 1.5rem; }
+th, td { padding: 0.4rem 0; border-bottom: 1px solid #ddd; text-align: left; }
+td.amount { text-align: right; font-variant-numeric: tabular-nums; }
+tr.total th, tr.total td { font-weight: bold; border-top: 2px solid #222; }
+td.note { color: #555; font-size: 0.9rem; }
+@media print { body { margin: 0; } p.draft { border-color: #000; background: none; } }
+</style>
+</head>
+<body>
+<h1>Statement {{.Period}}</h1>
+<p class="meta">Restaurant {{.RestaurantId}} &middot; {{.Currency}} &middot; {{date .From}} to {{through .To}}</p>
+{{if not .Final}}<p class="draft">The period is still running; these figures are provisional.</p>{{end}}
+<table>
+<tr><th>Opening balance</th><td class="amount">{{amount .OpeningBalance .Currency}}</td></tr>
+<tr><td class="note">of which held in escrow</td><td class="amount note">{{amount .OpeningEscrow .Currency}}</td></tr>
+<tr><td>Gross purchases ({{.Purchases}})</td><td class="amount">{{amount .GrossPurchases .Currency}}</td></tr>
+<tr><td>Commission deducted</td><td class="amount">{{amount (neg .CommissionDeducted) .Currency}}</td></tr>
+<tr><td>Refunds</td><td class="amount">{{amount (neg .Refunds) .Currency}}</td></tr>
+<tr><td>Payouts</td><td class="amount">{{amount (neg .Payouts) .Currency}}</td></tr>
+{{if .Adjustments}}<tr><td>Adjustments</td><td class="amount">{{amount .Adjustments .Currency}}</td></tr>{{end}}
+<tr class="total"><th>Closing balance</th><td class="amount">{{amount .ClosingBalance .Currency}}</td></tr>
+<tr><td class="note">of which held in escrow</td><td class="amount note">{{amount .ClosingEscrow .Currency}}</td></tr>
+</table>
+</body>
+</html>
+`))
+
+func renderHTML(w io.Writer, statement types.Statement) error {
+	return statementTemplate.Execute(w, statement)
+}
+
+// formatAmount writes m in major units with the currency's decimal places,
+// e.g. -1234 EUR as -12.34.
+func formatAmount(m types.Money, currency types.Currency) string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	digits := currency.MinorUnits()
+	if digits == 0 {
+		return fmt.Sprintf("%s%d", sign, value)
+	}
+
+	factor := currency.MinorUnitsPerMajor()
+	return fmt.Sprintf("%s%d.%0*d", sign, value/factor, digits, value%factor)
+}
@@ -0,0 +1,42 @@
+package transaction
+
+import (
+	"context"
+	"time"
+
+	"ledger-service/internal/core/types"
+)
+
+type CaptureHoldRequest struct {
+	Amount types.Money `json:"amount,omitempty" minimum:"0" doc:"Amount to capture in minor units, at most the held amount; captures the whole hold if omitted"`
+}
+
+type CaptureHoldInput struct {
+	TransactionId  string             `path:"transactionId" doc:"ID of the hold to capture"`
+	IdempotencyKey string             `header:"Idempotency-Key" maxLength:"255" doc:"Client generated key that makes retries of this request safe"`
+	Body           CaptureHoldRequest `json:"body"`
+}
+
+type CaptureHoldOutput struct {
+	Replayed string                 `header:"Idempotent-Replayed" doc:"Set to true when the response is replayed for a repeated Idempotency-Key"`
+	Body     GetTransactionResponse `json:"body"`
+}
+
+func (h *Handler) CaptureHold(ctx context.Context, input *CaptureHoldInput) (*CaptureHoldOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	purchase, replayed, err := h.ledgerService.CaptureHold(ctxWithTimeout, input.TransactionId, input.Body.Amount, input.IdempotencyKey)
+	if err != nil {
+		return nil, toCreateError("Failed to capture hold", err)
+	}
+
+	output := &CaptureHoldOutput{
+		Body: ToGetTransactionResponse(purchase),
+	}
+	if replayed {
+		output.Replayed = "true"
+	}
+
+	return output, nil
+}
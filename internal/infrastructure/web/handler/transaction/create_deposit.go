@@ -4,35 +4,40 @@ import (
 	"context"
 	"time"
 
-	"github.com/danielgtaylor/huma/v2"
 	"ledger-service/internal/core/types"
 )
 
 type DepositRequest struct {
-	Amount float32 `json:"amount" doc:"Deposit amount"`
+	Amount   types.Money    `json:"amount" minimum:"1" doc:"Deposit amount in minor units of the currency (e.g. cents)"`
+	Currency types.Currency `json:"currency,omitempty" pattern:"^[A-Z]{3}$" doc:"ISO 4217 currency code, defaults to the service currency"`
 }
 
 type DepositInput struct {
-	CustomerId string         `path:"customerId" doc:"Customer ID"`
-	Body       DepositRequest `json:"body"`
+	CustomerId     string         `path:"customerId" doc:"Customer ID"`
+	IdempotencyKey string         `header:"Idempotency-Key" maxLength:"255" doc:"Client generated key that makes retries of this request safe"`
+	Body           DepositRequest `json:"body"`
 }
 
 type DepositOutput struct {
-	Body DepositResponse `json:"body"`
+	Replayed string          `header:"Idempotent-Replayed" doc:"Set to true when the response is replayed for a repeated Idempotency-Key"`
+	Body     DepositResponse `json:"body"`
 }
 
 type DepositResponse struct {
 	Id        string        `json:"id" doc:"Transaction ID"`
 	Type      string        `json:"type" doc:"Transaction type (always DEPOSIT)"`
-	Amount    float32       `json:"amount" doc:"Deposit amount"`
+	Status    string        `json:"status" doc:"Transaction status, PENDING until balances are updated"`
+	Amount    types.Money   `json:"amount" doc:"Deposit amount in minor units"`
+	Currency  string        `json:"currency" doc:"ISO 4217 currency code"`
 	Customer  *UserResponse `json:"customer" doc:"Customer who made the deposit"`
 	CreatedAt time.Time     `json:"createdAt" doc:"Transaction creation timestamp"`
 }
 
 func (req DepositRequest) ToTransaction(customerId string) types.Transaction {
 	return types.Transaction{
-		Type:   types.DEPOSIT,
-		Amount: req.Amount,
+		Type:     types.DEPOSIT,
+		Amount:   req.Amount,
+		Currency: req.Currency,
 		Customer: types.User{
 			Id:   customerId,
 			Type: types.CUSTOMER,
@@ -45,7 +50,9 @@ func ToDepositResponse(t types.Transaction) DepositResponse {
 	resp := DepositResponse{
 		Id:        t.Id,
 		Type:      string(t.Type),
+		Status:    string(t.Status),
 		Amount:    t.Amount,
+		Currency:  string(t.Currency),
 		CreatedAt: t.CreatedAt,
 	}
 
@@ -65,15 +72,19 @@ func (h *Handler) CreateDeposit(ctx context.Context, input *DepositInput) (*Depo
 
 	transaction := input.Body.ToTransaction(input.CustomerId)
 
-	id, err := h.ledgerService.SaveTransaction(ctxWithTimeout, transaction)
+	saved, replayed, err := h.ledgerService.SaveTransaction(ctxWithTimeout, transaction, input.IdempotencyKey)
 	if err != nil {
-		return nil, huma.Error400BadRequest("Failed to create deposit", err)
+		return nil, toCreateError("Failed to create deposit", err)
 	}
 
-	transaction.Id = id
-	response := ToDepositResponse(transaction)
+	response := ToDepositResponse(saved)
 
-	return &DepositOutput{
+	output := &DepositOutput{
 		Body: response,
-	}, nil
-}
\ No newline at end of file
+	}
+	if replayed {
+		output.Replayed = "true"
+	}
+
+	return output, nil
+}
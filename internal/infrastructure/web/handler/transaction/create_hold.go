@@ -0,0 +1,44 @@
+package transaction
+
+import (
+	"context"
+	"time"
+
+	"ledger-service/internal/core/types"
+)
+
+type HoldRequest struct {
+	Amount       types.Money    `json:"amount" minimum:"1" doc:"Amount to hold in minor units of the currency (e.g. cents)"`
+	Currency     types.Currency `json:"currency,omitempty" pattern:"^[A-Z]{3}$" doc:"ISO 4217 currency code, must match a customer wallet; defaults to the service currency"`
+	RestaurantId string         `json:"restaurantId" doc:"Restaurant the order is placed with"`
+}
+
+type HoldInput struct {
+	CustomerId     string      `path:"customerId" doc:"Customer ID"`
+	IdempotencyKey string      `header:"Idempotency-Key" maxLength:"255" doc:"Client generated key that makes retries of this request safe"`
+	Body           HoldRequest `json:"body"`
+}
+
+type HoldOutput struct {
+	Replayed string                 `header:"Idempotent-Replayed" doc:"Set to true when the response is replayed for a repeated Idempotency-Key"`
+	Body     GetTransactionResponse `json:"body"`
+}
+
+func (h *Handler) CreateHold(ctx context.Context, input *HoldInput) (*HoldOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	saved, replayed, err := h.ledgerService.PlaceHold(ctxWithTimeout, input.CustomerId, input.Body.RestaurantId, input.Body.Amount, input.Body.Currency, input.IdempotencyKey)
+	if err != nil {
+		return nil, toCreateError("Failed to place hold", err)
+	}
+
+	output := &HoldOutput{
+		Body: ToGetTransactionResponse(saved),
+	}
+	if replayed {
+		output.Replayed = "true"
+	}
+
+	return output, nil
+}
@@ -2,6 +2,7 @@ package transaction
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -9,32 +10,52 @@ import (
 )
 
 type PurchaseRequest struct {
-	Amount       float32 `json:"amount" doc:"Purchase amount"`
-	RestaurantId string  `json:"restaurantId" doc:"Restaurant ID"`
+	Amount       types.Money       `json:"amount,omitempty" minimum:"0" doc:"Purchase amount in minor units of the currency (e.g. cents). Required without an order breakdown; with one it defaults to, and must match, the order total"`
+	Currency     types.Currency    `json:"currency,omitempty" pattern:"^[A-Z]{3}$" doc:"ISO 4217 currency code, must match a customer wallet; defaults to the service currency"`
+	RestaurantId string            `json:"restaurantId" doc:"Restaurant ID"`
+	Items        []LineItemRequest `json:"items,omitempty" doc:"Line items ordered from the restaurant, credited to it"`
+	DeliveryFee  types.Money       `json:"deliveryFee,omitempty" minimum:"0" maximum:"1000000000000" doc:"Delivery fee in minor units, credited to the courier or to the restaurant without one"`
+	ServiceFee   types.Money       `json:"serviceFee,omitempty" minimum:"0" maximum:"1000000000000" doc:"Service fee in minor units, credited to the platform"`
+	Tip          types.Money       `json:"tip,omitempty" minimum:"0" maximum:"1000000000000" doc:"Tip in minor units, credited to the courier or to the restaurant without one"`
+	CourierId    string            `json:"courierId,omitempty" doc:"Courier delivering the order"`
+}
+
+type LineItemRequest struct {
+	Name      string      `json:"name" doc:"Item name"`
+	Quantity  int64       `json:"quantity" minimum:"1" maximum:"10000" doc:"Quantity ordered"`
+	UnitPrice types.Money `json:"unitPrice" minimum:"0" maximum:"1000000000000" doc:"Price of one item in minor units"`
 }
 
 type PurchaseInput struct {
-	CustomerId string          `path:"customerId" doc:"Customer ID"`
-	Body       PurchaseRequest `json:"body"`
+	CustomerId     string          `path:"customerId" doc:"Customer ID"`
+	IdempotencyKey string          `header:"Idempotency-Key" maxLength:"255" doc:"Client generated key that makes retries of this request safe"`
+	Body           PurchaseRequest `json:"body"`
 }
 
 type PurchaseOutput struct {
-	Body PurchaseResponse `json:"body"`
+	Replayed string           `header:"Idempotent-Replayed" doc:"Set to true when the response is replayed for a repeated Idempotency-Key"`
+	Body     PurchaseResponse `json:"body"`
 }
 
 type PurchaseResponse struct {
-	Id         string        `json:"id" doc:"Transaction ID"`
-	Type       string        `json:"type" doc:"Transaction type (always PURCHASE)"`
-	Amount     float32       `json:"amount" doc:"Purchase amount"`
-	Customer   *UserResponse `json:"customer" doc:"Customer who made the purchase"`
-	Restaurant *UserResponse `json:"restaurant" doc:"Restaurant involved in the purchase"`
-	CreatedAt  time.Time     `json:"createdAt" doc:"Transaction creation timestamp"`
+	Id         string         `json:"id" doc:"Transaction ID"`
+	Type       string         `json:"type" doc:"Transaction type (always PURCHASE)"`
+	Status     string         `json:"status" doc:"Transaction status, PENDING until balances are updated"`
+	Amount     types.Money    `json:"amount" doc:"Purchase amount in minor units"`
+	Currency   string         `json:"currency" doc:"ISO 4217 currency code"`
+	Customer   *UserResponse  `json:"customer" doc:"Customer who made the purchase"`
+	Restaurant *UserResponse  `json:"restaurant" doc:"Restaurant involved in the purchase"`
+	Courier    *UserResponse  `json:"courier,omitempty" doc:"Courier delivering the order"`
+	Order      *OrderResponse `json:"order,omitempty" doc:"Breakdown of the purchase into items, fees and tip"`
+	Legs       []LegResponse  `json:"legs,omitempty" doc:"Party credited with each component of the purchase"`
+	CreatedAt  time.Time      `json:"createdAt" doc:"Transaction creation timestamp"`
 }
 
 func (req PurchaseRequest) ToTransaction(customerId string) types.Transaction {
-	return types.Transaction{
-		Type:   types.PURCHASE,
-		Amount: req.Amount,
+	transaction := types.Transaction{
+		Type:     types.PURCHASE,
+		Amount:   req.Amount,
+		Currency: req.Currency,
 		Customer: types.User{
 			Id:   customerId,
 			Type: types.CUSTOMER,
@@ -45,13 +66,49 @@ func (req PurchaseRequest) ToTransaction(customerId string) types.Transaction {
 		},
 		CreatedAt: time.Now(),
 	}
+
+	if req.CourierId != "" {
+		transaction.Courier = &types.User{
+			Id:   req.CourierId,
+			Type: types.COURIER,
+		}
+	}
+
+	if req.hasOrder() {
+		order := &types.Order{
+			Items:       []types.LineItem{},
+			DeliveryFee: req.DeliveryFee,
+			ServiceFee:  req.ServiceFee,
+			Tip:         req.Tip,
+		}
+		for _, item := range req.Items {
+			order.Items = append(order.Items, types.LineItem{
+				Name:      item.Name,
+				Quantity:  item.Quantity,
+				UnitPrice: item.UnitPrice,
+			})
+		}
+		transaction.Order = order
+	}
+
+	return transaction
+}
+
+// hasOrder reports whether the purchase is broken down into items, fees and
+// tip rather than given as a single amount.
+func (req PurchaseRequest) hasOrder() bool {
+	return len(req.Items) > 0 || req.DeliveryFee > 0 || req.ServiceFee > 0 || req.Tip > 0
 }
 
 func ToPurchaseResponse(t types.Transaction) PurchaseResponse {
 	resp := PurchaseResponse{
 		Id:        t.Id,
 		Type:      string(t.Type),
+		Status:    string(t.Status),
 		Amount:    t.Amount,
+		Currency:  string(t.Currency),
+		Order:     ToOrderResponse(t.Order),
+		Legs:      ToLegResponses(t.Legs),
 		CreatedAt: t.CreatedAt,
 	}
 
@@ -69,6 +126,13 @@ func ToPurchaseResponse(t types.Transaction) PurchaseResponse {
 		}
 	}
 
+	if t.Courier != nil && t.Courier.Id != "" {
+		resp.Courier = &UserResponse{
+			Id:   t.Courier.Id,
+			Type: string(t.Courier.Type),
+		}
+	}
+
 	return resp
 }
 
@@ -76,17 +140,25 @@ func (h *Handler) CreatePurchase(ctx context.Context, input *PurchaseInput) (*Pu
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
+	if !input.Body.hasOrder() && input.Body.Amount < 1 {
+		return nil, huma.Error422UnprocessableEntity("Failed to create purchase", errors.New("amount is required without items"))
+	}
+
 	transaction := input.Body.ToTransaction(input.CustomerId)
 
-	id, err := h.ledgerService.SaveTransaction(ctxWithTimeout, transaction)
+	saved, replayed, err := h.ledgerService.SaveTransaction(ctxWithTimeout, transaction, input.IdempotencyKey)
 	if err != nil {
-		return nil, huma.Error400BadRequest("Failed to create purchase", err)
+		return nil, toCreateError("Failed to create purchase", err)
 	}
 
-	transaction.Id = id
-	response := ToPurchaseResponse(transaction)
+	response := ToPurchaseResponse(saved)
 
-	return &PurchaseOutput{
+	output := &PurchaseOutput{
 		Body: response,
-	}, nil
-}
\ No newline at end of file
+	}
+	if replayed {
+		output.Replayed = "true"
+	}
+
+	return output, nil
+}
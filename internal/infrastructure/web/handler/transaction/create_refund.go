@@ -0,0 +1,42 @@
+package transaction
+
+import (
+	"context"
+	"time"
+
+	"ledger-service/internal/core/types"
+)
+
+type RefundRequest struct {
+	Amount types.Money `json:"amount" minimum:"1" doc:"Amount to refund in minor units of the purchase currency, at most what remains refundable"`
+}
+
+type RefundInput struct {
+	TransactionId  string        `path:"transactionId" doc:"ID of the purchase to refund"`
+	IdempotencyKey string        `header:"Idempotency-Key" maxLength:"255" doc:"Client generated key that makes retries of this request safe"`
+	Body           RefundRequest `json:"body"`
+}
+
+type RefundOutput struct {
+	Replayed string                 `header:"Idempotent-Replayed" doc:"Set to true when the response is replayed for a repeated Idempotency-Key"`
+	Body     GetTransactionResponse `json:"body"`
+}
+
+func (h *Handler) CreateRefund(ctx context.Context, input *RefundInput) (*RefundOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	saved, replayed, err := h.ledgerService.RefundPurchase(ctxWithTimeout, input.TransactionId, input.Body.Amount, input.IdempotencyKey)
+	if err != nil {
+		return nil, toCreateError("Failed to create refund", err)
+	}
+
+	output := &RefundOutput{
+		Body: ToGetTransactionResponse(saved),
+	}
+	if replayed {
+		output.Replayed = "true"
+	}
+
+	return output, nil
+}
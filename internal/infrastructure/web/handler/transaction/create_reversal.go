@@ -0,0 +1,41 @@
+package transaction
+
+import (
+	"context"
+	"time"
+)
+
+type ReversalRequest struct {
+	Reason   string `json:"reason" minLength:"1" maxLength:"1000" doc:"Why the transaction is voided"`
+	Operator string `json:"operator" minLength:"1" maxLength:"255" doc:"Who voids the transaction"`
+}
+
+type ReversalInput struct {
+	TransactionId  string          `path:"transactionId" doc:"ID of the transaction to reverse"`
+	IdempotencyKey string          `header:"Idempotency-Key" maxLength:"255" doc:"Client generated key that makes retries of this request safe"`
+	Body           ReversalRequest `json:"body"`
+}
+
+type ReversalOutput struct {
+	Replayed string                 `header:"Idempotent-Replayed" doc:"Set to true when the response is replayed for a repeated Idempotency-Key"`
+	Body     GetTransactionResponse `json:"body"`
+}
+
+func (h *Handler) CreateReversal(ctx context.Context, input *ReversalInput) (*ReversalOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	saved, replayed, err := h.ledgerService.ReverseTransaction(ctxWithTimeout, input.TransactionId, input.Body.Reason, input.Body.Operator, input.IdempotencyKey)
+	if err != nil {
+		return nil, toCreateError("Failed to create reversal", err)
+	}
+
+	output := &ReversalOutput{
+		Body: ToGetTransactionResponse(saved),
+	}
+	if replayed {
+		output.Replayed = "true"
+	}
+
+	return output, nil
+}
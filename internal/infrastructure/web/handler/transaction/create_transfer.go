@@ -0,0 +1,63 @@
+package transaction
+
+import (
+	"context"
+	"time"
+
+	"ledger-service/internal/core/types"
+)
+
+type TransferRequest struct {
+	Amount      types.Money    `json:"amount" minimum:"1" doc:"Transfer amount in minor units of the currency (e.g. cents)"`
+	Currency    types.Currency `json:"currency,omitempty" pattern:"^[A-Z]{3}$" doc:"ISO 4217 currency code, must match a wallet of the sender; defaults to the service currency"`
+	RecipientId string         `json:"recipientId" minLength:"1" doc:"ID of the customer receiving the transfer"`
+}
+
+type TransferInput struct {
+	CustomerId     string          `path:"customerId" doc:"ID of the customer sending the transfer"`
+	IdempotencyKey string          `header:"Idempotency-Key" maxLength:"255" doc:"Client generated key that makes retries of this request safe"`
+	Body           TransferRequest `json:"body"`
+}
+
+type TransferOutput struct {
+	Replayed string                 `header:"Idempotent-Replayed" doc:"Set to true when the response is replayed for a repeated Idempotency-Key"`
+	Body     GetTransactionResponse `json:"body"`
+}
+
+func (req TransferRequest) ToTransaction(customerId string) types.Transaction {
+	return types.Transaction{
+		Type:     types.TRANSFER,
+		Amount:   req.Amount,
+		Currency: req.Currency,
+		Customer: types.User{
+			Id:   customerId,
+			Type: types.CUSTOMER,
+		},
+		Recipient: &types.User{
+			Id:   req.RecipientId,
+			Type: types.CUSTOMER,
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+func (h *Handler) CreateTransfer(ctx context.Context, input *TransferInput) (*TransferOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	transaction := input.Body.ToTransaction(input.CustomerId)
+
+	saved, replayed, err := h.ledgerService.SaveTransaction(ctxWithTimeout, transaction, input.IdempotencyKey)
+	if err != nil {
+		return nil, toCreateError("Failed to create transfer", err)
+	}
+
+	output := &TransferOutput{
+		Body: ToGetTransactionResponse(saved),
+	}
+	if replayed {
+		output.Replayed = "true"
+	}
+
+	return output, nil
+}
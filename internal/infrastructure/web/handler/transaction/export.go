@@ -0,0 +1,263 @@
+package transaction
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
+// exportTimeout bounds how long an export may stream for.
+const exportTimeout = 10 * time.Minute
+
+// exportFlushEvery is how many rows are written between flushes to the client.
+const exportFlushEvery = 100
+
+type exportFormat string
+
+const (
+	exportCSV    exportFormat = "csv"
+	exportNDJSON exportFormat = "ndjson"
+)
+
+func (f exportFormat) contentType() string {
+	if f == exportNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ExportParams are the parameters of the transaction exports.
+type ExportParams struct {
+	FilterParams
+	Format string `query:"format" enum:"csv,ndjson" doc:"Export format; overrides the Accept header"`
+	Accept string `header:"Accept" doc:"text/csv (the default), or application/x-ndjson or application/jsonl; anything else is answered with 406"`
+}
+
+type ExportOutput struct {
+	ContentType        string `header:"Content-Type"`
+	ContentDisposition string `header:"Content-Disposition"`
+	Body               func(ctx huma.Context)
+}
+
+// exportColumns are the CSV columns, and NDJSON fields, of an exported
+// transaction. They are part of the export format: new columns are only ever
+// added at the end, and existing ones are never renamed or removed.
+var exportColumns = []string{
+	"id",
+	"created_at",
+	"posted_at",
+	"type",
+	"status",
+	"amount",
+	"currency",
+	"customer_id",
+	"restaurant_id",
+	"recipient_id",
+	"courier_id",
+	"platform_id",
+	"related_transaction",
+	"commission_rate",
+	"failure_reason",
+}
+
+// ExportRecord is an exported transaction. Amounts are in minor units, times
+// in RFC 3339 UTC, and fields that do not apply are empty.
+type ExportRecord struct {
+	Id                 string `json:"id"`
+	CreatedAt          string `json:"created_at"`
+	PostedAt           string `json:"posted_at"`
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Amount             int64  `json:"amount"`
+	Currency           string `json:"currency"`
+	CustomerId         string `json:"customer_id"`
+	RestaurantId       string `json:"restaurant_id"`
+	RecipientId        string `json:"recipient_id"`
+	CourierId          string `json:"courier_id"`
+	PlatformId         string `json:"platform_id"`
+	RelatedTransaction string `json:"related_transaction"`
+	CommissionRate     string `json:"commission_rate"`
+	FailureReason      string `json:"failure_reason"`
+}
+
+func ToExportRecord(t types.Transaction) ExportRecord {
+	record := ExportRecord{
+		Id:                 t.Id,
+		CreatedAt:          t.CreatedAt.UTC().Format(time.RFC3339Nano),
+		Type:               string(t.Type),
+		Status:             string(t.Status),
+		Amount:             int64(t.Amount),
+		Currency:           string(t.Currency),
+		CustomerId:         t.Customer.Id,
+		RestaurantId:       t.Restaurant.Id,
+		RelatedTransaction: t.RelatedTransaction,
+		FailureReason:      t.FailureReason,
+	}
+
+	if t.Recipient != nil {
+		record.RecipientId = t.Recipient.Id
+	}
+	if t.Platform != nil {
+		record.PlatformId = t.Platform.Id
+	}
+	if t.Courier != nil {
+		record.CourierId = t.Courier.Id
+	}
+	if t.PostedAt != nil {
+		record.PostedAt = t.PostedAt.UTC().Format(time.RFC3339Nano)
+	}
+	if t.Type == types.COMMISSION || t.Type == types.COMMISSION_REFUND {
+		record.CommissionRate = strconv.FormatInt(int64(t.CommissionRate), 10)
+	}
+
+	return record
+}
+
+// row returns the record's values in exportColumns order, with the text
+// escaped by csvText.
+func (r ExportRecord) row() []string {
+	return []string{
+		csvText(r.Id),
+		csvText(r.CreatedAt),
+		csvText(r.PostedAt),
+		csvText(r.Type),
+		csvText(r.Status),
+		strconv.FormatInt(r.Amount, 10),
+		csvText(r.Currency),
+		csvText(r.CustomerId),
+		csvText(r.RestaurantId),
+		csvText(r.RecipientId),
+		csvText(r.CourierId),
+		csvText(r.PlatformId),
+		csvText(r.RelatedTransaction),
+		csvText(r.CommissionRate),
+		csvText(r.FailureReason),
+	}
+}
+
+// csvText keeps spreadsheets from running a cell as a formula: ids and failure
+// reasons come from clients, so a cell starting with =, +, - or @ is prefixed
+// with a single quote.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// negotiateExportFormat picks the export format from the format parameter,
+// or else from the first media type in the Accept header that is supported.
+// Without either the export is CSV. application/json is not supported: the
+// NDJSON export is not one JSON document.
+func negotiateExportFormat(format, accept string) (exportFormat, error) {
+	if format != "" {
+		return exportFormat(format), nil
+	}
+	if strings.TrimSpace(accept) == "" {
+		return exportCSV, nil
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		switch mediaType {
+		case "text/csv", "text/*", "*/*":
+			return exportCSV, nil
+		case "application/x-ndjson", "application/jsonl":
+			return exportNDJSON, nil
+		}
+	}
+	return "", fmt.Errorf("none of %q can be produced; use text/csv or application/x-ndjson", accept)
+}
+
+type exportRowFunc = func(types.Transaction) error
+
+type exportFunc func(ctx context.Context, fn exportRowFunc) error
+
+// streamExport returns an output that streams the transactions produced by
+// export as they are read. Once streaming started the status cannot change,
+// so an export that fails midway is cut short and the error is logged.
+func streamExport(params ExportParams, filename string, export exportFunc) (*ExportOutput, error) {
+	// Checked up front, as errors met once streaming started cannot be
+	// reported.
+	if err := params.Filter().Validate(); err != nil {
+		return nil, huma.Error400BadRequest("Invalid filter", err)
+	}
+
+	format, err := negotiateExportFormat(params.Format, params.Accept)
+	if err != nil {
+		return nil, huma.NewError(http.StatusNotAcceptable, "Unsupported export format", err)
+	}
+
+	return &ExportOutput{
+		ContentType:        format.contentType(),
+		ContentDisposition: fmt.Sprintf("attachment; filename=%q", filename+"."+string(format)),
+		Body: func(hctx huma.Context) {
+			ctx, cancel := context.WithTimeout(hctx.Context(), exportTimeout)
+			defer cancel()
+
+			w := hctx.BodyWriter()
+			if err := writeExport(ctx, w, format, export); err != nil {
+				slog.Error("Transaction export failed", "error", err.Error(), "filename", filename)
+			}
+		},
+	}, nil
+}
+
+func writeExport(ctx context.Context, w io.Writer, format exportFormat, export exportFunc) error {
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if format == exportNDJSON {
+		encoder := json.NewEncoder(w)
+		rows := 0
+		return export(ctx, func(t types.Transaction) error {
+			if err := encoder.Encode(ToExportRecord(t)); err != nil {
+				return err
+			}
+			if rows++; rows%exportFlushEvery == 0 {
+				flush()
+			}
+			return nil
+		})
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportColumns); err != nil {
+		return err
+	}
+
+	rows := 0
+	err := export(ctx, func(t types.Transaction) error {
+		if err := writer.Write(ToExportRecord(t).row()); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushEvery == 0 {
+			writer.Flush()
+			flush()
+		}
+		return writer.Error()
+	})
+
+	writer.Flush()
+	return errors.Join(err, writer.Error())
+}
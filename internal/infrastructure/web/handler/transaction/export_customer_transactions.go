@@ -0,0 +1,16 @@
+package transaction
+
+import (
+	"context"
+)
+
+type ExportCustomerTransactionsInput struct {
+	CustomerId string `path:"customerId" doc:"Customer ID"`
+	ExportParams
+}
+
+func (h *Handler) ExportCustomerTransactions(ctx context.Context, input *ExportCustomerTransactionsInput) (*ExportOutput, error) {
+	return streamExport(input.ExportParams, "transactions-"+input.CustomerId, func(ctx context.Context, fn exportRowFunc) error {
+		return h.ledgerService.ExportCustomerTransactions(ctx, input.CustomerId, input.Filter(), fn)
+	})
+}
@@ -0,0 +1,16 @@
+package transaction
+
+import (
+	"context"
+)
+
+type ExportRestaurantTransactionsInput struct {
+	RestaurantId string `path:"restaurantId" doc:"Restaurant ID"`
+	ExportParams
+}
+
+func (h *Handler) ExportRestaurantTransactions(ctx context.Context, input *ExportRestaurantTransactionsInput) (*ExportOutput, error) {
+	return streamExport(input.ExportParams, "transactions-"+input.RestaurantId, func(ctx context.Context, fn exportRowFunc) error {
+		return h.ledgerService.ExportRestaurantTransactions(ctx, input.RestaurantId, input.Filter(), fn)
+	})
+}
@@ -0,0 +1,63 @@
+package transaction
+
+import "testing"
+
+func TestNegotiateExportFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		accept  string
+		want    exportFormat
+		wantErr bool
+	}{
+		{"no preference", "", "", exportCSV, false},
+		{"csv", "", "text/csv", exportCSV, false},
+		{"ndjson", "", "application/x-ndjson", exportNDJSON, false},
+		{"jsonl", "", "application/jsonl", exportNDJSON, false},
+		{"any", "", "*/*", exportCSV, false},
+		{"any text", "", "text/*", exportCSV, false},
+		{"first supported wins", "", "application/xml, application/x-ndjson, text/csv", exportNDJSON, false},
+		{"parameters ignored", "", "text/csv; charset=utf-8; q=0.9", exportCSV, false},
+		{"format parameter overrides accept", "ndjson", "text/csv", exportNDJSON, false},
+		{"json is not ndjson", "", "application/json", "", true},
+		{"nothing supported", "", "application/xml, image/png", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := negotiateExportFormat(tt.format, tt.accept)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("negotiateExportFormat(%q, %q) = %q, want an error", tt.format, tt.accept, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("negotiateExportFormat(%q, %q) returned error: %v", tt.format, tt.accept, err)
+			}
+			if got != tt.want {
+				t.Errorf("negotiateExportFormat(%q, %q) = %q, want %q", tt.format, tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"customer-1", "customer-1"},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"a=b", "a=b"},
+	}
+
+	for _, tt := range tests {
+		if got := csvText(tt.in); got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package transaction
+
+import (
+	"context"
+	"time"
+)
+
+type GetCourierTransactionsInput struct {
+	CourierId string `path:"courierId" doc:"Courier ID"`
+	ListParams
+}
+
+type GetCourierTransactionsOutput struct {
+	Next string                   `header:"Next-Cursor" doc:"Cursor for the next page; absent on the last page"`
+	Body []GetTransactionResponse `json:"body"`
+}
+
+func (h *Handler) GetCourierTransactions(ctx context.Context, input *GetCourierTransactionsInput) (*GetCourierTransactionsOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	transactions, next, err := h.ledgerService.GetCourierTransactions(ctxWithTimeout, input.CourierId, input.Filter(), input.Cursor, input.Limit)
+	if err != nil {
+		return nil, toListError(err)
+	}
+
+	responses := []GetTransactionResponse{}
+	for _, transaction := range transactions {
+		responses = append(responses, ToGetTransactionResponse(transaction))
+	}
+
+	return &GetCourierTransactionsOutput{
+		Next: next,
+		Body: responses,
+	}, nil
+}
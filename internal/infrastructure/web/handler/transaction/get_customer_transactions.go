@@ -4,33 +4,41 @@ import (
 	"context"
 	"time"
 
-	"github.com/danielgtaylor/huma/v2"
 	"ledger-service/internal/core/types"
 )
 
 type GetCustomerTransactionsInput struct {
 	CustomerId string `path:"customerId" doc:"Customer ID"`
+	ListParams
 }
 
 type GetCustomerTransactionsOutput struct {
+	Next string                            `header:"Next-Cursor" doc:"Cursor for the next page; absent on the last page"`
 	Body []GetCustomerTransactionsResponse `json:"body"`
 }
 
 type GetCustomerTransactionsResponse struct {
-	Id         string        `json:"id" doc:"Transaction ID"`
-	Type       string        `json:"type" doc:"Transaction type"`
-	Amount     float32       `json:"amount" doc:"Transaction amount"`
-	User       *UserResponse `json:"user,omitempty" doc:"User"`
-	Restaurant *UserResponse `json:"restaurant,omitempty" doc:"Restaurant involved in the transaction"`
-	CreatedAt  time.Time     `json:"createdAt" doc:"Transaction creation timestamp"`
+	Id                 string        `json:"id" doc:"Transaction ID"`
+	Type               string        `json:"type" doc:"Transaction type"`
+	Status             string        `json:"status" doc:"Transaction status"`
+	Amount             types.Money   `json:"amount" doc:"Transaction amount in minor units"`
+	Currency           string        `json:"currency" doc:"ISO 4217 currency code"`
+	User               *UserResponse `json:"user,omitempty" doc:"User"`
+	Restaurant         *UserResponse `json:"restaurant,omitempty" doc:"Restaurant involved in the transaction"`
+	Recipient          *UserResponse `json:"recipient,omitempty" doc:"Customer receiving the transaction (transfers only); user is the sender"`
+	RelatedTransaction string        `json:"relatedTransaction,omitempty" doc:"Related transaction ID (the purchase for refunds, the voided transaction for reversals)"`
+	CreatedAt          time.Time     `json:"createdAt" doc:"Transaction creation timestamp"`
 }
 
 func ToGetCustomerTransactionsResponse(t types.Transaction) GetCustomerTransactionsResponse {
 	resp := GetCustomerTransactionsResponse{
-		Id:        t.Id,
-		Type:      string(t.Type),
-		Amount:    t.Amount,
-		CreatedAt: t.CreatedAt,
+		Id:                 t.Id,
+		Type:               string(t.Type),
+		Status:             string(t.Status),
+		Amount:             t.Amount,
+		Currency:           string(t.Currency),
+		RelatedTransaction: t.RelatedTransaction,
+		CreatedAt:          t.CreatedAt,
 	}
 
 	if t.Customer.Id != "" {
@@ -47,6 +55,13 @@ func ToGetCustomerTransactionsResponse(t types.Transaction) GetCustomerTransacti
 		}
 	}
 
+	if t.Recipient != nil && t.Recipient.Id != "" {
+		resp.Recipient = &UserResponse{
+			Id:   t.Recipient.Id,
+			Type: string(t.Recipient.Type),
+		}
+	}
+
 	return resp
 }
 
@@ -54,9 +69,9 @@ func (h *Handler) GetCustomerTransactions(ctx context.Context, input *GetCustome
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	transactions, err := h.ledgerService.GetCustomerTransactions(ctxWithTimeout, input.CustomerId)
+	transactions, next, err := h.ledgerService.GetCustomerTransactions(ctxWithTimeout, input.CustomerId, input.Filter(), input.Cursor, input.Limit)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to retrieve transactions", err)
+		return nil, toListError(err)
 	}
 
 	responses := []GetCustomerTransactionsResponse{}
@@ -65,6 +80,7 @@ func (h *Handler) GetCustomerTransactions(ctx context.Context, input *GetCustome
 	}
 
 	return &GetCustomerTransactionsOutput{
+		Next: next,
 		Body: responses,
 	}, nil
-}
\ No newline at end of file
+}
@@ -4,25 +4,30 @@ import (
 	"context"
 	"time"
 
-	"github.com/danielgtaylor/huma/v2"
 	"ledger-service/internal/core/types"
 )
 
 type GetRestaurantTransactionsInput struct {
 	RestaurantId string `path:"restaurantId" doc:"Restaurant ID"`
+	ListParams
 }
 
 type GetRestaurantTransactionsOutput struct {
+	Next string                              `header:"Next-Cursor" doc:"Cursor for the next page; absent on the last page"`
 	Body []GetRestaurantTransactionsResponse `json:"body"`
 }
 
 type GetRestaurantTransactionsResponse struct {
 	Id                 string        `json:"id" doc:"Transaction ID"`
 	Type               string        `json:"type" doc:"Transaction type"`
-	Amount             float32       `json:"amount" doc:"Transaction amount"`
+	Status             string        `json:"status" doc:"Transaction status"`
+	Amount             types.Money   `json:"amount" doc:"Transaction amount in minor units"`
+	Currency           string        `json:"currency" doc:"ISO 4217 currency code"`
 	Customer           *UserResponse `json:"customer,omitempty" doc:"Customer involved in the transaction"`
 	Restaurant         *UserResponse `json:"restaurant,omitempty" doc:"Restaurant involved in the transaction"`
-	RelatedTransaction string        `json:"relatedTransaction,omitempty" doc:"Related transaction ID (for commission transactions)"`
+	Platform           *UserResponse `json:"platform,omitempty" doc:"Platform account credited by the transaction (commission only)"`
+	RelatedTransaction string        `json:"relatedTransaction,omitempty" doc:"Related transaction ID (the purchase for commissions and refunds, the refund for commission refunds, the voided transaction for reversals, the purchase for escrow releases)"`
+	CommissionRate     *types.Rate   `json:"commissionRate,omitempty" doc:"Commission rate applied in basis points (for commission transactions)"`
 	CreatedAt          time.Time     `json:"createdAt" doc:"Transaction creation timestamp"`
 }
 
@@ -30,7 +35,9 @@ func ToGetRestaurantTransactionsResponse(t types.Transaction) GetRestaurantTrans
 	resp := GetRestaurantTransactionsResponse{
 		Id:                 t.Id,
 		Type:               string(t.Type),
+		Status:             string(t.Status),
 		Amount:             t.Amount,
+		Currency:           string(t.Currency),
 		RelatedTransaction: t.RelatedTransaction,
 		CreatedAt:          t.CreatedAt,
 	}
@@ -49,6 +56,17 @@ func ToGetRestaurantTransactionsResponse(t types.Transaction) GetRestaurantTrans
 		}
 	}
 
+	if t.Type == types.COMMISSION || t.Type == types.COMMISSION_REFUND {
+		resp.CommissionRate = &t.CommissionRate
+	}
+
+	if t.Platform != nil && t.Platform.Id != "" {
+		resp.Platform = &UserResponse{
+			Id:   t.Platform.Id,
+			Type: string(t.Platform.Type),
+		}
+	}
+
 	return resp
 }
 
@@ -56,9 +74,9 @@ func (h *Handler) GetRestaurantTransactions(ctx context.Context, input *GetResta
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	transactions, err := h.ledgerService.GetRestaurantTransactions(ctxWithTimeout, input.RestaurantId)
+	transactions, next, err := h.ledgerService.GetRestaurantTransactions(ctxWithTimeout, input.RestaurantId, input.Filter(), input.Cursor, input.Limit)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to retrieve transactions", err)
+		return nil, toListError(err)
 	}
 
 	responses := []GetRestaurantTransactionsResponse{}
@@ -67,6 +85,7 @@ func (h *Handler) GetRestaurantTransactions(ctx context.Context, input *GetResta
 	}
 
 	return &GetRestaurantTransactionsOutput{
+		Next: next,
 		Body: responses,
 	}, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,140 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
+type GetTransactionInput struct {
+	TransactionId string `path:"transactionId" doc:"Transaction ID"`
+}
+
+type GetTransactionOutput struct {
+	Body GetTransactionResponse `json:"body"`
+}
+
+type GetTransactionResponse struct {
+	Id                 string         `json:"id" doc:"Transaction ID"`
+	Type               string         `json:"type" doc:"Transaction type"`
+	Status             string         `json:"status" doc:"Transaction status: PENDING until balances are updated, then POSTED or FAILED"`
+	FailureReason      string         `json:"failureReason,omitempty" doc:"Why the balance update failed (FAILED only)"`
+	Amount             types.Money    `json:"amount" doc:"Transaction amount in minor units"`
+	Currency           string         `json:"currency" doc:"ISO 4217 currency code"`
+	Customer           *UserResponse  `json:"customer,omitempty" doc:"Customer involved in the transaction"`
+	Restaurant         *UserResponse  `json:"restaurant,omitempty" doc:"Restaurant involved in the transaction"`
+	Recipient          *UserResponse  `json:"recipient,omitempty" doc:"Customer receiving the transaction (transfers only)"`
+	Platform           *UserResponse  `json:"platform,omitempty" doc:"Platform account credited by the transaction (commission and purchases with a service fee)"`
+	Courier            *UserResponse  `json:"courier,omitempty" doc:"Courier delivering the order (purchases only)"`
+	Order              *OrderResponse `json:"order,omitempty" doc:"Breakdown of the purchase into items, fees and tip"`
+	Legs               []LegResponse  `json:"legs,omitempty" doc:"Party credited with each component of the purchase"`
+	RelatedTransaction string         `json:"relatedTransaction,omitempty" doc:"Related transaction ID (the purchase for commissions and refunds, the refund for commission refunds, the voided transaction for reversals, the hold for captured purchases, the purchase for escrow releases)"`
+	CommissionRate     *types.Rate    `json:"commissionRate,omitempty" doc:"Commission rate applied in basis points (for commission transactions)"`
+	RefundedAmount     *types.Money   `json:"refundedAmount,omitempty" doc:"Amount claimed by refunds in minor units, posted or pending (purchases only)"`
+	PayoutStatus       string         `json:"payoutStatus,omitempty" doc:"Payout workflow status (payouts only)"`
+	HoldStatus         string         `json:"holdStatus,omitempty" doc:"Hold status: ACTIVE, CAPTURED, RELEASED or EXPIRED (holds only)"`
+	ExpiresAt          *time.Time     `json:"expiresAt,omitempty" doc:"When the hold is released automatically if it is still active (holds only)"`
+	EscrowStatus       string         `json:"escrowStatus,omitempty" doc:"Whether the proceeds are HELD in the restaurant's escrow or RELEASED (purchases only)"`
+	EscrowAmount       *types.Money   `json:"escrowAmount,omitempty" doc:"Proceeds still held in escrow in minor units, net of refunds (purchases only)"`
+	EscrowReleaseAt    *time.Time     `json:"escrowReleaseAt,omitempty" doc:"When held proceeds are released if the order is not marked delivered (purchases only)"`
+	DeliveredAt        *time.Time     `json:"deliveredAt,omitempty" doc:"When the order was marked delivered (purchases only)"`
+	Reversed           bool           `json:"reversed,omitempty" doc:"Whether a reversal of the transaction was accepted"`
+	Reason             string         `json:"reason,omitempty" doc:"Why the transaction was made (reversals only)"`
+	Operator           string         `json:"operator,omitempty" doc:"Who made the transaction (reversals only)"`
+	CreatedAt          time.Time      `json:"createdAt" doc:"Transaction creation timestamp"`
+	PostedAt           *time.Time     `json:"postedAt,omitempty" doc:"When the transaction was applied to balances (POSTED only)"`
+}
+
+func ToGetTransactionResponse(t types.Transaction) GetTransactionResponse {
+	resp := GetTransactionResponse{
+		Id:                 t.Id,
+		Type:               string(t.Type),
+		Status:             string(t.Status),
+		FailureReason:      t.FailureReason,
+		Amount:             t.Amount,
+		Currency:           string(t.Currency),
+		RelatedTransaction: t.RelatedTransaction,
+		Order:              ToOrderResponse(t.Order),
+		Legs:               ToLegResponses(t.Legs),
+		PayoutStatus:       string(t.PayoutStatus),
+		HoldStatus:         string(t.HoldStatus),
+		ExpiresAt:          t.ExpiresAt,
+		EscrowStatus:       string(t.EscrowStatus),
+		EscrowReleaseAt:    t.EscrowReleaseAt,
+		DeliveredAt:        t.DeliveredAt,
+		Reversed:           t.Reversed,
+		Reason:             t.Reason,
+		Operator:           t.Operator,
+		CreatedAt:          t.CreatedAt,
+		PostedAt:           t.PostedAt,
+	}
+
+	if t.Customer.Id != "" {
+		resp.Customer = &UserResponse{
+			Id:   t.Customer.Id,
+			Type: string(t.Customer.Type),
+		}
+	}
+
+	if t.Restaurant.Id != "" {
+		resp.Restaurant = &UserResponse{
+			Id:   t.Restaurant.Id,
+			Type: string(t.Restaurant.Type),
+		}
+	}
+
+	if t.Type == types.COMMISSION || t.Type == types.COMMISSION_REFUND {
+		resp.CommissionRate = &t.CommissionRate
+	}
+
+	if t.Type == types.PURCHASE {
+		resp.RefundedAmount = &t.RefundedAmount
+	}
+
+	if t.EscrowStatus == types.ESCROW_HELD {
+		resp.EscrowAmount = &t.EscrowAmount
+	}
+
+	if t.Recipient != nil && t.Recipient.Id != "" {
+		resp.Recipient = &UserResponse{
+			Id:   t.Recipient.Id,
+			Type: string(t.Recipient.Type),
+		}
+	}
+
+	if t.Courier != nil && t.Courier.Id != "" {
+		resp.Courier = &UserResponse{
+			Id:   t.Courier.Id,
+			Type: string(t.Courier.Type),
+		}
+	}
+
+	if t.Platform != nil && t.Platform.Id != "" {
+		resp.Platform = &UserResponse{
+			Id:   t.Platform.Id,
+			Type: string(t.Platform.Type),
+		}
+	}
+
+	return resp
+}
+
+func (h *Handler) GetTransaction(ctx context.Context, input *GetTransactionInput) (*GetTransactionOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	transaction, err := h.ledgerService.GetTransaction(ctxWithTimeout, input.TransactionId)
+	if err != nil {
+		if errors.Is(err, types.ErrTransactionNotFound) {
+			return nil, huma.Error404NotFound("Transaction not found", err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to retrieve transaction", err)
+	}
+
+	return &GetTransactionOutput{
+		Body: ToGetTransactionResponse(transaction),
+	}, nil
+}
@@ -0,0 +1,64 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
+type GetTransactionPostingsInput struct {
+	TransactionId string `path:"transactionId" doc:"Transaction ID"`
+}
+
+type GetTransactionPostingsOutput struct {
+	Body []PostingResponse `json:"body"`
+}
+
+type PostingResponse struct {
+	Id           string       `json:"id" doc:"Posting ID"`
+	Account      string       `json:"account" doc:"Account the posting is booked against (user ID or system account)"`
+	Amount       types.Money  `json:"amount" doc:"Signed amount in minor units: positive credits, negative debits the account"`
+	Currency     string       `json:"currency" doc:"ISO 4217 currency code"`
+	Kind         string       `json:"kind" doc:"What the posting is for, e.g. PRINCIPAL or COMMISSION"`
+	Escrow       bool         `json:"escrow,omitempty" doc:"Whether the posting is booked against the account's escrow rather than its balance"`
+	BalanceAfter *types.Money `json:"balanceAfter,omitempty" doc:"Account balance right after the posting was applied"`
+	PostedAt     time.Time    `json:"postedAt" doc:"When the posting was booked"`
+}
+
+func ToPostingResponse(p types.Posting) PostingResponse {
+	return PostingResponse{
+		Id:           p.Id,
+		Account:      p.Account,
+		Amount:       p.Amount,
+		Currency:     string(p.Currency),
+		Kind:         string(p.Kind),
+		Escrow:       p.Escrow,
+		BalanceAfter: p.BalanceAfter,
+		PostedAt:     p.PostedAt,
+	}
+}
+
+func (h *Handler) GetTransactionPostings(ctx context.Context, input *GetTransactionPostingsInput) (*GetTransactionPostingsOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	postings, err := h.ledgerService.GetPostings(ctxWithTimeout, input.TransactionId)
+	if err != nil {
+		if errors.Is(err, types.ErrTransactionNotFound) {
+			return nil, huma.Error404NotFound("Transaction not found", err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to retrieve postings", err)
+	}
+
+	responses := []PostingResponse{}
+	for _, posting := range postings {
+		responses = append(responses, ToPostingResponse(posting))
+	}
+
+	return &GetTransactionPostingsOutput{
+		Body: responses,
+	}, nil
+}
@@ -0,0 +1,28 @@
+package transaction
+
+import (
+	"context"
+	"time"
+)
+
+type MarkDeliveredInput struct {
+	TransactionId string `path:"transactionId" doc:"ID of the purchase whose order was delivered"`
+}
+
+type MarkDeliveredOutput struct {
+	Body GetTransactionResponse `json:"body"`
+}
+
+func (h *Handler) MarkDelivered(ctx context.Context, input *MarkDeliveredInput) (*MarkDeliveredOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	purchase, err := h.ledgerService.MarkDelivered(ctxWithTimeout, input.TransactionId)
+	if err != nil {
+		return nil, toCreateError("Failed to mark order delivered", err)
+	}
+
+	return &MarkDeliveredOutput{
+		Body: ToGetTransactionResponse(purchase),
+	}, nil
+}
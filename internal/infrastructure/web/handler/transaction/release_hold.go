@@ -0,0 +1,28 @@
+package transaction
+
+import (
+	"context"
+	"time"
+)
+
+type ReleaseHoldInput struct {
+	TransactionId string `path:"transactionId" doc:"ID of the hold to release"`
+}
+
+type ReleaseHoldOutput struct {
+	Body GetTransactionResponse `json:"body"`
+}
+
+func (h *Handler) ReleaseHold(ctx context.Context, input *ReleaseHoldInput) (*ReleaseHoldOutput, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	hold, err := h.ledgerService.ReleaseHold(ctxWithTimeout, input.TransactionId)
+	if err != nil {
+		return nil, toCreateError("Failed to release hold", err)
+	}
+
+	return &ReleaseHoldOutput{
+		Body: ToGetTransactionResponse(hold),
+	}, nil
+}
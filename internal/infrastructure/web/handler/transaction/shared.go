@@ -1,6 +1,137 @@
 package transaction
 
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"ledger-service/internal/core/types"
+)
+
 type UserResponse struct {
 	Id   string `json:"id" doc:"User ID"`
 	Type string `json:"type" doc:"User type"`
-}
\ No newline at end of file
+}
+
+type LineItemResponse struct {
+	Name      string      `json:"name" doc:"Item name"`
+	Quantity  int64       `json:"quantity" doc:"Quantity ordered"`
+	UnitPrice types.Money `json:"unitPrice" doc:"Price of one item in minor units"`
+}
+
+type OrderResponse struct {
+	Items       []LineItemResponse `json:"items" doc:"Line items ordered from the restaurant"`
+	Subtotal    types.Money        `json:"subtotal" doc:"Total of the line items in minor units"`
+	DeliveryFee types.Money        `json:"deliveryFee" doc:"Delivery fee in minor units"`
+	ServiceFee  types.Money        `json:"serviceFee" doc:"Service fee in minor units"`
+	Tip         types.Money        `json:"tip" doc:"Tip in minor units"`
+}
+
+type LegResponse struct {
+	Component      string       `json:"component" doc:"Order component: ITEMS, DELIVERY_FEE, SERVICE_FEE or TIP"`
+	Payee          UserResponse `json:"payee" doc:"Party credited with the component"`
+	Amount         types.Money  `json:"amount" doc:"Amount credited in minor units"`
+	Commissionable bool         `json:"commissionable" doc:"Whether commission is charged on the leg"`
+}
+
+func ToOrderResponse(order *types.Order) *OrderResponse {
+	if order == nil {
+		return nil
+	}
+
+	// The order was priced when the purchase was placed, so it fits.
+	subtotal, _ := order.Subtotal()
+	resp := &OrderResponse{
+		Items:       []LineItemResponse{},
+		Subtotal:    subtotal,
+		DeliveryFee: order.DeliveryFee,
+		ServiceFee:  order.ServiceFee,
+		Tip:         order.Tip,
+	}
+	for _, item := range order.Items {
+		resp.Items = append(resp.Items, LineItemResponse{
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	return resp
+}
+
+func ToLegResponses(legs []types.Leg) []LegResponse {
+	if len(legs) == 0 {
+		return nil
+	}
+
+	resp := []LegResponse{}
+	for _, leg := range legs {
+		resp = append(resp, LegResponse{
+			Component:      string(leg.Component),
+			Payee:          UserResponse{Id: leg.Payee.Id, Type: string(leg.Payee.Type)},
+			Amount:         leg.Amount,
+			Commissionable: leg.Commissionable,
+		})
+	}
+	return resp
+}
+
+// FilterParams are the filters of the transaction listings and exports.
+type FilterParams struct {
+	Type      []types.TransactionType `query:"type" doc:"Only return transactions of these types, comma separated: DEPOSIT, PURCHASE, COMMISSION, REFUND, COMMISSION_REFUND, REVERSAL, PAYOUT, TRANSFER, HOLD or ESCROW_RELEASE"`
+	MinAmount types.Money             `query:"minAmount" minimum:"0" doc:"Only return transactions of at least this amount in minor units; must not exceed maxAmount"`
+	MaxAmount types.Money             `query:"maxAmount" minimum:"0" doc:"Only return transactions of at most this amount in minor units"`
+	From      time.Time               `query:"from" doc:"Only return transactions created at or after this instant; must be before to"`
+	To        time.Time               `query:"to" doc:"Only return transactions created before this instant"`
+}
+
+// ListParams are the pagination and filter parameters of the transaction
+// listings.
+type ListParams struct {
+	FilterParams
+	Cursor string `query:"cursor" doc:"Cursor returned in Next-Cursor by the previous page"`
+	Limit  int    `query:"limit" minimum:"1" maximum:"500" default:"50" doc:"Maximum number of transactions to return"`
+}
+
+func (p FilterParams) Filter() types.TransactionFilter {
+	return types.TransactionFilter{
+		Types:     p.Type,
+		MinAmount: p.MinAmount,
+		MaxAmount: p.MaxAmount,
+		From:      p.From,
+		To:        p.To,
+	}
+}
+
+// toListError maps errors returned while listing transactions to HTTP errors.
+func toListError(err error) error {
+	if errors.Is(err, types.ErrInvalidCursor) {
+		return huma.Error400BadRequest("Invalid cursor", err)
+	}
+	if errors.Is(err, types.ErrInvalidFilter) {
+		return huma.Error400BadRequest("Invalid filter", err)
+	}
+	return huma.Error500InternalServerError("Failed to retrieve transactions", err)
+}
+
+// toCreateError maps errors returned while creating a transaction to HTTP
+// errors, falling back to 400 for anything not recognised.
+func toCreateError(msg string, err error) error {
+	switch {
+	case errors.Is(err, types.ErrUnsupportedCurrency), errors.Is(err, types.ErrCurrencyMismatch),
+		errors.Is(err, types.ErrIdempotencyKeyReused), errors.Is(err, types.ErrRefundExceedsPurchase),
+		errors.Is(err, types.ErrSelfTransfer), errors.Is(err, types.ErrCaptureExceedsHold),
+		errors.Is(err, types.ErrInvalidOrder):
+		return huma.Error422UnprocessableEntity(msg, err)
+	case errors.Is(err, types.ErrIdempotencyKeyInProgress), errors.Is(err, types.ErrNotRefundable),
+		errors.Is(err, types.ErrNotReversible), errors.Is(err, types.ErrAlreadyReversed),
+		errors.Is(err, types.ErrHoldNotActive), errors.Is(err, types.ErrEscrowNotHeld):
+		return huma.Error409Conflict(msg, err)
+	case errors.Is(err, types.ErrTransactionNotFound), errors.Is(err, types.ErrHoldNotFound):
+		return huma.Error404NotFound(msg, err)
+	case errors.Is(err, types.ErrInsufficientFunds):
+		return huma.NewError(http.StatusPaymentRequired, msg, err)
+	default:
+		return huma.Error400BadRequest(msg, err)
+	}
+}
@@ -2,10 +2,16 @@ package web
 
 import (
 	"ledger-service/internal/core/services/ledger"
+	"ledger-service/internal/infrastructure/web/handler/admin"
 	"ledger-service/internal/infrastructure/web/handler/balance"
+	"ledger-service/internal/infrastructure/web/handler/commission"
+	"ledger-service/internal/infrastructure/web/handler/payout"
+	"ledger-service/internal/infrastructure/web/handler/platform"
+	"ledger-service/internal/infrastructure/web/handler/statement"
 	"ledger-service/internal/infrastructure/web/handler/transaction"
 	"ledger-service/internal/infrastructure/web/middleware"
 	"net/http"
+	"reflect"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humago"
@@ -15,6 +21,11 @@ type Server struct {
 	api                huma.API
 	balanceHandler     *balance.Handler
 	transactionHandler *transaction.Handler
+	platformHandler    *platform.Handler
+	commissionHandler  *commission.Handler
+	payoutHandler      *payout.Handler
+	statementHandler   *statement.Handler
+	adminHandler       *admin.Handler
 }
 
 func NewServer(ledgerService *ledger.Service) *Server {
@@ -27,6 +38,11 @@ func NewServer(ledgerService *ledger.Service) *Server {
 		api:                api,
 		balanceHandler:     balance.NewHandler(ledgerService),
 		transactionHandler: transaction.NewHandler(ledgerService),
+		platformHandler:    platform.NewHandler(ledgerService),
+		commissionHandler:  commission.NewHandler(ledgerService),
+		payoutHandler:      payout.NewHandler(ledgerService),
+		statementHandler:   statement.NewHandler(ledgerService),
+		adminHandler:       admin.NewHandler(ledgerService),
 	}
 
 	server.registerRoutes()
@@ -41,7 +57,7 @@ func (s *Server) registerRoutes() {
 		Summary:     "Create a deposit",
 		Description: "Create a deposit transaction for a customer. Called by other services when customer adds money.",
 		Tags:        []string{"transactions"},
-		Errors:      []int{400, 500},
+		Errors:      []int{400, 409, 422, 500},
 	}, s.transactionHandler.CreateDeposit)
 
 	huma.Register(s.api, huma.Operation{
@@ -49,29 +65,139 @@ func (s *Server) registerRoutes() {
 		Method:      http.MethodPost,
 		Path:        "/api/customers/{customerId}/transactions/purchase",
 		Summary:     "Create a purchase",
-		Description: "Create a purchase transaction for a customer. Called by other services when customer buys from restaurant.",
+		Description: "Create a purchase transaction for a customer. Called by other services when customer buys from restaurant, either for a single amount or broken down into items, delivery fee, service fee and tip that are credited to the restaurant, courier and platform. Fails with 402 if the customer's available balance plus overdraft limit does not cover the amount.",
 		Tags:        []string{"transactions"},
-		Errors:      []int{400, 500},
+		Errors:      []int{400, 402, 409, 422, 500},
 	}, s.transactionHandler.CreatePurchase)
 
+	huma.Register(s.api, huma.Operation{
+		OperationID: "create-transfer",
+		Method:      http.MethodPost,
+		Path:        "/api/customers/{customerId}/transactions/transfers",
+		Summary:     "Create a transfer",
+		Description: "Send balance from one customer to another, e.g. to split a bill. Fails with 402 if the sender's available balance plus overdraft limit does not cover the amount.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{400, 402, 409, 422, 500},
+	}, s.transactionHandler.CreateTransfer)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "create-hold",
+		Method:      http.MethodPost,
+		Path:        "/api/customers/{customerId}/transactions/holds",
+		Summary:     "Place a hold",
+		Description: "Set funds aside for an order that is not confirmed yet. The hold lowers the available balance but not the ledger balance, and expires after the configured TTL. Fails with 402 like a purchase.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{400, 402, 409, 422, 500},
+	}, s.transactionHandler.CreateHold)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "capture-hold",
+		Method:      http.MethodPost,
+		Path:        "/api/transactions/{transactionId}/capture",
+		Summary:     "Capture a hold",
+		Description: "Turn an active hold into a PURCHASE, optionally for less than the held amount, and release the rest. Returns the purchase; retries with the same Idempotency-Key return it again. Fails with 409 if the hold is not active or expired.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{400, 404, 409, 422, 500},
+	}, s.transactionHandler.CaptureHold)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "release-hold",
+		Method:      http.MethodPost,
+		Path:        "/api/transactions/{transactionId}/release",
+		Summary:     "Release a hold",
+		Description: "Free the funds of an active hold. Fails with 409 if the hold is not active.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{400, 404, 409, 500},
+	}, s.transactionHandler.ReleaseHold)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "mark-delivered",
+		Method:      http.MethodPost,
+		Path:        "/api/transactions/{transactionId}/delivered",
+		Summary:     "Mark an order delivered",
+		Description: "Release a posted purchase's proceeds from the restaurant's escrow to its balance. Fails with 409 if they are not held in escrow, e.g. because they were already released.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{400, 404, 409, 500},
+	}, s.transactionHandler.MarkDelivered)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "create-refund",
+		Method:      http.MethodPost,
+		Path:        "/api/transactions/{transactionId}/refunds",
+		Summary:     "Refund a purchase",
+		Description: "Refund part or all of a posted purchase. The customer is credited, the restaurant debited and the commission on the refunded part returned to the restaurant once the refund is POSTED. Fails with 422 if the amount exceeds what remains refundable and 409 if the transaction is not a posted purchase.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{400, 404, 409, 422, 500},
+	}, s.transactionHandler.CreateRefund)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "create-reversal",
+		Method:      http.MethodPost,
+		Path:        "/api/transactions/{transactionId}/reversals",
+		Summary:     "Reverse a transaction",
+		Description: "Void a posted transaction. Once the REVERSAL is POSTED it has undone every balance change of the original. Fails with 409 if the transaction is not posted, was already reversed, is a reversal or is a refunded purchase.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{400, 404, 409, 422, 500},
+	}, s.transactionHandler.CreateReversal)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-transaction",
+		Method:      http.MethodGet,
+		Path:        "/api/transactions/{transactionId}",
+		Summary:     "Get a transaction",
+		Description: "Retrieve a single transaction. Poll its status to learn whether the balance update was POSTED or FAILED.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{404, 500},
+	}, s.transactionHandler.GetTransaction)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-transaction-postings",
+		Method:      http.MethodGet,
+		Path:        "/api/transactions/{transactionId}/postings",
+		Summary:     "Get transaction postings",
+		Description: "Retrieve the double-entry journal lines booked for a transaction. Empty until the transaction is POSTED.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{404, 500},
+	}, s.transactionHandler.GetTransactionPostings)
+
 	huma.Register(s.api, huma.Operation{
 		OperationID: "get-balance",
 		Method:      http.MethodGet,
 		Path:        "/api/balances/{userId}",
-		Summary:     "Get user balance",
-		Description: "Retrieve the current balance for a specific user. Returns 0 balance for new users.",
+		Summary:     "Get user balances",
+		Description: "Retrieve the current balance in every currency held by a specific user, optionally filtered to one currency. Returns 0 balance for a requested currency the user does not hold. With asOf, returns the balances at that instant computed from the journal; reserved amounts and overdraft limits are not kept historically and are reported as 0.",
 		Tags:        []string{"balances"},
-		Errors:      []int{500},
+		Errors:      []int{422, 500},
 	}, s.balanceHandler.GetBalance)
 
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-balance-history",
+		Method:      http.MethodGet,
+		Path:        "/api/balances/{userId}/history",
+		Summary:     "Get balance history",
+		Description: "List the changes to a user's balances, oldest first, each with the balance it resulted in, for rendering a statement with a running balance. Paginated: pass the returned next cursor to get the following page.",
+		Tags:        []string{"balances"},
+		Errors:      []int{400, 422, 500},
+	}, s.balanceHandler.GetBalanceHistory)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "set-overdraft-limit",
+		Method:      http.MethodPut,
+		Path:        "/api/balances/{userId}/overdraft-limit",
+		Summary:     "Set overdraft limit",
+		Description: "Allow a user's wallet in one currency to be spent below zero, up to the given limit.",
+		Tags:        []string{"balances"},
+		Errors:      []int{422, 500},
+	}, s.balanceHandler.SetOverdraftLimit)
+
 	huma.Register(s.api, huma.Operation{
 		OperationID: "get-customer-transactions",
 		Method:      http.MethodGet,
 		Path:        "/api/customers/{customerId}/transactions",
 		Summary:     "Get customer transactions",
-		Description: "Retrieve all transactions for a specific customer",
+		Description: "Retrieve a customer's transactions, newest first, optionally filtered by type, amount and creation time. Paginated: pass the Next-Cursor response header as cursor to get the following page.",
 		Tags:        []string{"transactions"},
-		Errors:      []int{500},
+		Errors:      []int{400, 500},
 	}, s.transactionHandler.GetCustomerTransactions)
 
 	huma.Register(s.api, huma.Operation{
@@ -79,10 +205,200 @@ func (s *Server) registerRoutes() {
 		Method:      http.MethodGet,
 		Path:        "/api/restaurants/{restaurantId}/transactions",
 		Summary:     "Get restaurant transactions",
-		Description: "Retrieve all transactions for a specific restaurant",
+		Description: "Retrieve a restaurant's transactions, newest first, filtered and paginated like customer transactions. Called with the platform account ID, it returns the commission transactions credited to the platform.",
 		Tags:        []string{"transactions"},
-		Errors:      []int{500},
+		Errors:      []int{400, 500},
 	}, s.transactionHandler.GetRestaurantTransactions)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-courier-transactions",
+		Method:      http.MethodGet,
+		Path:        "/api/couriers/{courierId}/transactions",
+		Summary:     "Get courier transactions",
+		Description: "Retrieve the purchases delivered by a courier and their reversals, filtered and paginated like customer transactions. Each purchase lists its legs, including the delivery fee and tip paid to the courier.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{400, 500},
+	}, s.transactionHandler.GetCourierTransactions)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "export-customer-transactions",
+		Method:      http.MethodGet,
+		Path:        "/api/customers/{customerId}/transactions/export",
+		Summary:     "Export customer transactions",
+		Description: "Stream all of a customer's transactions matching the listing filters, oldest first, as CSV or NDJSON. The format is taken from the format parameter or the Accept header and defaults to CSV.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{406, 500},
+	}, s.transactionHandler.ExportCustomerTransactions)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "export-restaurant-transactions",
+		Method:      http.MethodGet,
+		Path:        "/api/restaurants/{restaurantId}/transactions/export",
+		Summary:     "Export restaurant transactions",
+		Description: "Stream all of a restaurant's transactions matching the listing filters, oldest first, as CSV or NDJSON, like customer exports.",
+		Tags:        []string{"transactions"},
+		Errors:      []int{406, 500},
+	}, s.transactionHandler.ExportRestaurantTransactions)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-restaurant-statement",
+		Method:      http.MethodGet,
+		Path:        "/api/restaurants/{restaurantId}/statements/{period}",
+		Summary:     "Get restaurant statement",
+		Description: "Retrieve a restaurant's statement for a calendar month (UTC): opening balance, gross purchases, commission deducted, refunds, payouts and closing balance, computed from the postings of its transactions. Returned as JSON, or as printable HTML with format=html or an Accept header preferring text/html. Fails with 400 for a malformed period or one that has not started.",
+		Tags:        []string{"statements"},
+		Errors:      []int{400, 422, 500},
+		Responses: map[string]*huma.Response{
+			"200": {
+				Description: "Restaurant statement",
+				Content: map[string]*huma.MediaType{
+					"application/json": {Schema: s.api.OpenAPI().Components.Schemas.Schema(reflect.TypeOf(statement.StatementResponse{}), true, "")},
+					"text/html":        {Schema: &huma.Schema{Type: huma.TypeString}},
+				},
+			},
+		},
+	}, s.statementHandler.GetRestaurantStatement)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "create-payout",
+		Method:      http.MethodPost,
+		Path:        "/api/restaurants/{restaurantId}/payouts",
+		Summary:     "Request a payout",
+		Description: "Request paying part of a restaurant's balance out. The amount is reserved right away; fails with 402 if the balance not reserved for pending refunds and payouts does not cover it.",
+		Tags:        []string{"payouts"},
+		Errors:      []int{402, 409, 422, 500},
+	}, s.payoutHandler.CreatePayout)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-payouts",
+		Method:      http.MethodGet,
+		Path:        "/api/restaurants/{restaurantId}/payouts",
+		Summary:     "List payouts",
+		Description: "Retrieve every payout of a restaurant, newest first.",
+		Tags:        []string{"payouts"},
+		Errors:      []int{500},
+	}, s.payoutHandler.GetPayouts)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-payout",
+		Method:      http.MethodGet,
+		Path:        "/api/restaurants/{restaurantId}/payouts/{payoutId}",
+		Summary:     "Get a payout",
+		Description: "Retrieve a single payout and its workflow status.",
+		Tags:        []string{"payouts"},
+		Errors:      []int{404, 500},
+	}, s.payoutHandler.GetPayout)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "approve-payout",
+		Method:      http.MethodPost,
+		Path:        "/api/restaurants/{restaurantId}/payouts/{payoutId}/approve",
+		Summary:     "Approve a payout",
+		Description: "Approve a REQUESTED payout. Fails with 409 in any other status.",
+		Tags:        []string{"payouts"},
+		Errors:      []int{404, 409, 500},
+	}, s.payoutHandler.ApprovePayout)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "settle-payout",
+		Method:      http.MethodPost,
+		Path:        "/api/restaurants/{restaurantId}/payouts/{payoutId}/settle",
+		Summary:     "Settle a payout",
+		Description: "Record that an APPROVED payout was paid. The amount then leaves the restaurant's balance and the payout becomes POSTED. Fails with 409 in any other status.",
+		Tags:        []string{"payouts"},
+		Errors:      []int{404, 409, 500},
+	}, s.payoutHandler.SettlePayout)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "reject-payout",
+		Method:      http.MethodPost,
+		Path:        "/api/restaurants/{restaurantId}/payouts/{payoutId}/reject",
+		Summary:     "Reject a payout",
+		Description: "Reject a REQUESTED or APPROVED payout, releasing its reserved amount. The payout becomes FAILED. Fails with 409 once it is settled.",
+		Tags:        []string{"payouts"},
+		Errors:      []int{404, 409, 500},
+	}, s.payoutHandler.RejectPayout)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-platform-revenue",
+		Method:      http.MethodGet,
+		Path:        "/api/platform/revenue",
+		Summary:     "Get platform revenue",
+		Description: "Report the commission credited to the platform account per currency over a period.",
+		Tags:        []string{"platform"},
+		Errors:      []int{400, 500},
+	}, s.platformHandler.GetRevenue)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-commission-policies",
+		Method:      http.MethodGet,
+		Path:        "/api/commission-policies",
+		Summary:     "List commission policies",
+		Description: "Retrieve the default commission policy and every per-restaurant policy.",
+		Tags:        []string{"commission"},
+		Errors:      []int{500},
+	}, s.commissionHandler.GetPolicies)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-default-commission-policy",
+		Method:      http.MethodGet,
+		Path:        "/api/commission-policies/default",
+		Summary:     "Get the default commission policy",
+		Description: "Retrieve the commission rate charged to restaurants without their own policy.",
+		Tags:        []string{"commission"},
+		Errors:      []int{500},
+	}, s.commissionHandler.GetDefaultPolicy)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "set-default-commission-policy",
+		Method:      http.MethodPut,
+		Path:        "/api/commission-policies/default",
+		Summary:     "Set the default commission policy",
+		Description: "Set the commission rate charged to restaurants without their own policy. Applies to purchases posted from now on.",
+		Tags:        []string{"commission"},
+		Errors:      []int{422, 500},
+	}, s.commissionHandler.SetDefaultPolicy)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "get-restaurant-commission-policy",
+		Method:      http.MethodGet,
+		Path:        "/api/restaurants/{restaurantId}/commission-policy",
+		Summary:     "Get a restaurant's commission policy",
+		Description: "Retrieve the commission rate negotiated with a restaurant. Returns 404 if the restaurant uses the default policy.",
+		Tags:        []string{"commission"},
+		Errors:      []int{404, 500},
+	}, s.commissionHandler.GetRestaurantPolicy)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "set-restaurant-commission-policy",
+		Method:      http.MethodPut,
+		Path:        "/api/restaurants/{restaurantId}/commission-policy",
+		Summary:     "Set a restaurant's commission policy",
+		Description: "Create or replace the commission rate negotiated with a restaurant. Applies to purchases posted from now on.",
+		Tags:        []string{"commission"},
+		Errors:      []int{422, 500},
+	}, s.commissionHandler.SetRestaurantPolicy)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID:   "delete-restaurant-commission-policy",
+		Method:        http.MethodDelete,
+		Path:          "/api/restaurants/{restaurantId}/commission-policy",
+		Summary:       "Delete a restaurant's commission policy",
+		Description:   "Remove the restaurant's own commission rate so that the default policy applies to it again.",
+		Tags:          []string{"commission"},
+		DefaultStatus: http.StatusNoContent,
+		Errors:        []int{404, 500},
+	}, s.commissionHandler.DeleteRestaurantPolicy)
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "run-reconciliation",
+		Method:      http.MethodPost,
+		Path:        "/api/admin/reconciliations",
+		Summary:     "Reconcile balances",
+		Description: "Check every wallet's stored balance, escrow and total commission against its journal postings and report the wallets that drifted. With repair set, drifted wallets are corrected. Fails with 409 if a reconciliation is already running.",
+		Tags:        []string{"admin"},
+		Errors:      []int{409, 500},
+	}, s.adminHandler.RunReconciliation)
 }
 
 func (s *Server) Handler() http.Handler {